@@ -0,0 +1,61 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// BenchmarkImport runs a full WLC import of ./morphwlc to the json sink and
+// reports its wall time, CPU time and peak RSS. MORPH_BENCH names the morph
+// binaries to compare as name=path pairs separated by spaces; without it
+// the tree being tested is built and measured. `make bench` compares the
+// commit before the single-encode change with the current tree.
+func BenchmarkImport(b *testing.B) {
+	source, err := filepath.Abs(filepath.Join("..", "..", "morphwlc"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	binaries := os.Getenv("MORPH_BENCH")
+	if len(binaries) == 0 {
+		binary := filepath.Join(b.TempDir(), "morph")
+		output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput()
+		if err != nil {
+			b.Fatalf("go build: %s\n%s", err, output)
+		}
+		binaries = "current=" + binary
+	}
+	for _, pair := range strings.Fields(binaries) {
+		name, binary, ok := strings.Cut(pair, "=")
+		if !ok {
+			b.Fatalf("MORPH_BENCH: expected name=path but found %q", pair)
+		}
+		b.Run(name, func(b *testing.B) {
+			var cpu time.Duration
+			var peak int64
+			for i := 0; i < b.N; i++ {
+				cmd := exec.Command(binary, "-mode", "wlc", "-sink", "json")
+				cmd.Dir = b.TempDir()
+				cmd.Env = append(os.Environ(), "SOURCE="+source)
+				output, err := cmd.CombinedOutput()
+				if err != nil {
+					b.Fatalf("%s: %s\n%s", binary, err, output)
+				}
+				usage := cmd.ProcessState.SysUsage().(*syscall.Rusage)
+				cpu += time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+				//+ Linux counts Maxrss in kilobytes
+				if usage.Maxrss > peak {
+					peak = usage.Maxrss
+				}
+			}
+			b.ReportMetric(cpu.Seconds()/float64(b.N), "cpu-s/op")
+			b.ReportMetric(float64(peak)/1024, "peak-MB")
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/util"
+)
+
+type decoded struct {
+	Code       string      `json:"code"`
+	Morphology interface{} `json:"morphology"`
+	Parsing    string      `json:"parsing,omitempty"`
+}
+
+// formatWlc writes morphology in the key=value form encode accepts
+func formatWlc(morphology []corpus.WlcMorphology) string {
+	var groups []string
+	for _, m := range morphology {
+		groups = append(groups, m.String())
+	}
+	return "language=" + morphology[0].Language + "," + strings.Join(groups, "|")
+}
+
+// decodeCommand prints the morphology of each code given, e.g.
+// morph decode HVqp3ms or morph decode -mode gnt V- 3AAI-S--
+func decodeCommand(args []string) {
+	flags := flag.NewFlagSet("decode", flag.ExitOnError)
+	modePtr := flags.String("mode", "wlc", "gnt|wlc")
+	jsonPtr := flags.Bool("json", false, "write one JSON object per code")
+	parsingPtr := flags.String("parsing", "", "print the parsing instead: full|compact|leipzig")
+	localePtr := flags.String("locale", "", "JSON file translating parsing labels")
+	flags.Parse(args)
+	formatter, err := newFormatter(*parsingPtr, *localePtr)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	codes := flags.Args()
+	if len(codes) == 0 {
+		util.Errorf("usage: morph decode [-mode gnt|wlc] [-json] [-parsing full|compact|leipzig] CODE...")
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	for len(codes) > 0 {
+		var result decoded
+		var text string
+		switch *modePtr {
+		case "wlc":
+			morphology, err := corpus.DecodeWlc(codes[0])
+			if err != nil {
+				util.Errorf(err.Error())
+			}
+			result = decoded{Code: codes[0], Morphology: morphology}
+			text = formatWlc(morphology)
+			if formatter != nil {
+				result.Parsing = formatter.Wlc(morphology)
+				text = result.Parsing
+			}
+			codes = codes[1:]
+		case "gnt":
+			//+ the part and code may be given as one argument or two
+			part, code := codes[0], ""
+			if len(part) == 10 {
+				part, code = part[:2], part[2:]
+				codes = codes[1:]
+			} else if len(codes) > 1 {
+				code = codes[1]
+				codes = codes[2:]
+			} else {
+				util.Errorf("expected a part and code, e.g. V- 3AAI-S--")
+			}
+			morphology, err := corpus.DecodeGnt(part, code)
+			if err != nil {
+				util.Errorf(err.Error())
+			}
+			result = decoded{Code: part + " " + code, Morphology: morphology}
+			text = morphology.String()
+			if formatter != nil {
+				result.Parsing = formatter.Gnt(morphology)
+				text = result.Parsing
+			}
+		default:
+			util.Errorf("-mode must be gnt or wlc")
+		}
+		if *jsonPtr {
+			err := encoder.Encode(result)
+			if err != nil {
+				util.Errorf(err.Error())
+			}
+			continue
+		}
+		fmt.Printf("%s\t%s\n", result.Code, text)
+	}
+}
+
+// encodeCommand prints the code for morphology described in words, e.g.
+// morph encode Hebrew qal perfect 3ms
+func encodeCommand(args []string) {
+	flags := flag.NewFlagSet("encode", flag.ExitOnError)
+	modePtr := flags.String("mode", "wlc", "gnt|wlc")
+	flags.Parse(args)
+	description := strings.Join(flags.Args(), " ")
+	if len(strings.TrimSpace(description)) == 0 {
+		util.Errorf("usage: morph encode [-mode gnt|wlc] DESCRIPTION")
+	}
+	switch *modePtr {
+	case "wlc":
+		morphology, err := corpus.ParseWlcDescription(description)
+		if err != nil {
+			util.Errorf(err.Error())
+		}
+		code, err := corpus.EncodeWlc(morphology...)
+		if err != nil {
+			util.Errorf(err.Error())
+		}
+		fmt.Println(code)
+	case "gnt":
+		morphology, err := corpus.ParseGntDescription(description)
+		if err != nil {
+			util.Errorf(err.Error())
+		}
+		part, code, err := corpus.EncodeGnt(morphology)
+		if err != nil {
+			util.Errorf(err.Error())
+		}
+		fmt.Println(part, code)
+	default:
+		util.Errorf("-mode must be gnt or wlc")
+	}
+}
@@ -1,42 +1,263 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 
-	"github.com/davidbetz/morph/internal/parser"
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/checkpoint"
+	"github.com/davidbetz/morph/internal/importer"
 	"github.com/davidbetz/morph/internal/platform"
+	"github.com/davidbetz/morph/internal/retry"
 	"github.com/davidbetz/morph/internal/util"
 )
 
 var verbose bool
 
-type activeParser interface {
-	Process() error
+// exitInterrupted is the exit code of an import stopped by SIGINT or
+// SIGTERM, as a shell reports a process killed by SIGINT
+const exitInterrupted = 130
+
+type sinkList []string
+
+func (s *sinkList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *sinkList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) > 0 {
+			*s = append(*s, name)
+		}
+	}
+	return nil
+}
+
+func getenv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return fallback
+	}
+	return value
+}
+
+// selectsCorpus reports whether the selection reaches any book of mode's
+// corpus, wlc or gnt. An empty selection selects every book.
+func selectsCorpus(selection []corpus.Range, mode string) bool {
+	if len(selection) == 0 {
+		return true
+	}
+	for _, r := range selection {
+		if r.Corpus() == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// newFormatter creates the formatter for -parsing, or nil when no parsing
+// was asked for
+func newFormatter(style string, localeFile string) (*corpus.Formatter, error) {
+	if len(style) == 0 {
+		if len(localeFile) > 0 {
+			return nil, errors.New("-locale needs -parsing")
+		}
+		return nil, nil
+	}
+	var locale corpus.Locale
+	if len(localeFile) > 0 {
+		var err error
+		locale, err = corpus.LoadLocale(localeFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return corpus.NewFormatter(style, locale)
+}
+
+var commands = map[string]func(args []string){
+	"decode":        decodeCommand,
+	"encode":        encodeCommand,
+	"validate":      validateCommand,
+	"versification": versificationCommand,
 }
 
 func main() {
 	verbose, _ = strconv.ParseBool(os.Getenv("VERBOSE"))
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
 	modePtr := flag.String("mode", "", "gnt|wlc")
-	stylePtr := flag.String("style", "", "english|hebrew")
+	stylePtr := flag.String("style", "", "english|hebrew|both")
+	morphemesPtr := flag.Bool("morphemes", false, "wlc: save one row per morpheme")
+	booksPtr := flag.String("books", "", "comma-separated books to import, e.g. Gen,Exod,1 Samuel")
+	continuePtr := flag.Bool("continue-on-error", false, "skip words that fail to parse and report them all at the end")
+	rangePtr := flag.String("range", "", "references to import, e.g. \"Gen 1:1-2:3; Ps 23\"")
+	parsingPtr := flag.String("parsing", "", "add a parsing to every word: full|compact|leipzig")
+	localePtr := flag.String("locale", "", "JSON file translating parsing labels")
+	granularityPtr := flag.String("granularity", importer.GranularityWord, "save one record per "+strings.Join(importer.Granularities, "|"))
+	resumePtr := flag.Bool("resume", false, "skip the books and partitions the checkpoint says were saved")
+	checkpointPtr := flag.String("checkpoint", "", "checkpoint file (default TABLE_NAME.checkpoint.json)")
+	manifestPtr := flag.String("manifest", "", "manifest written when the run completes (default TABLE_NAME.manifest.json)")
+	transliteratePtr := flag.Bool("transliterate", false, "wlc: add SBL academic and simple transliterations")
+	attemptsPtr := flag.Int("attempts", retry.Default.Attempts, "times a cloud sink tries a write that fails for a transient reason")
+	backoffPtr := flag.Duration("backoff", retry.Default.Delay, "longest wait before the first retry; it doubles with each retry, up to "+retry.Default.MaxDelay.String())
+	ratePtr := flag.Float64("rate", 0, "records each cloud sink writes per second (0 for no limit)")
+	concurrencyPtr := flag.Int("concurrency", 1, "books parsed and partitions written at once (aws, azure and gcp; file sinks and mssql write in order)")
+	var sinks sinkList
+	flag.Var(&sinks, "sink", strings.Join(platform.Names(), "|")+" (comma-separated or repeated)")
 	flag.Parse()
 	mode := *modePtr
 	if len(mode) == 0 {
 		util.Errorf("-mode is required: gnt|wlc")
 	}
-	err := platform.ValidateCloudConfig()
+	if len(sinks) == 0 {
+		util.Errorf("-sink is required: %s", strings.Join(platform.Names(), "|"))
+	}
+	sink, err := platform.CreateFanout(sinks)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	selection, err := corpus.ParseSelection(*booksPtr, *rangePtr)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	if len(selection) > 0 {
+		var selected []string
+		for _, r := range selection {
+			selected = append(selected, r.String())
+		}
+		fmt.Printf("Selected: %s\n", strings.Join(selected, "; "))
+	}
+	if !selectsCorpus(selection, mode) {
+		util.Errorf("-books and -range select no %s book", mode)
+	}
+	formatter, err := newFormatter(*parsingPtr, *localePtr)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	if *concurrencyPtr < 1 {
+		util.Errorf("-concurrency must be at least 1")
+	}
+	if *attemptsPtr < 1 {
+		util.Errorf("-attempts must be at least 1")
+	}
+	if *backoffPtr < 0 {
+		util.Errorf("-backoff can't be negative")
+	}
+	if *ratePtr < 0 {
+		util.Errorf("-rate can't be negative")
+	}
+	granularity := *granularityPtr
+	if !importer.ValidGranularity(granularity) {
+		util.Errorf("-granularity must be %s", strings.Join(importer.Granularities, "|"))
+	}
+	//+ documents get their own table, since their keys differ from words
+	suffix := ""
+	if granularity != importer.GranularityWord {
+		suffix = "_" + granularity
+	}
+	table := getenv("TABLE_NAME", "morphgnt"+suffix)
+	source := getenv("SOURCE", "./morphgnt/")
+	if mode == "wlc" {
+		table = getenv("TABLE_NAME", "morphwlc"+suffix)
+		source = getenv("SOURCE", "./morphhb/")
+	}
+	checkpointFile := *checkpointPtr
+	if len(checkpointFile) == 0 {
+		checkpointFile = table + ".checkpoint.json"
+	}
+	manifest := *manifestPtr
+	if len(manifest) == 0 {
+		manifest = table + ".manifest.json"
+	}
+	//+ everything that changes what gets written, so a checkpoint only
+	//+ resumes the same import
+	runOptions := make(map[string]string)
+	for name, value := range map[string]string{
+		"source":        source,
+		"style":         *stylePtr,
+		"books":         *booksPtr,
+		"range":         *rangePtr,
+		"granularity":   granularity,
+		"parsing":       *parsingPtr,
+		"locale":        *localePtr,
+		"morphemes":     fmt.Sprint(*morphemesPtr),
+		"transliterate": fmt.Sprint(*transliteratePtr),
+	} {
+		if len(value) > 0 && value != "false" {
+			runOptions[name] = value
+		}
+	}
+	progress, err := checkpoint.Open(checkpointFile, checkpoint.Run{
+		Mode:    mode,
+		Table:   table,
+		Sinks:   sinks,
+		Options: runOptions,
+	}, *resumePtr)
 	if err != nil {
 		util.Errorf(err.Error())
 	}
-	var activeParser activeParser
+	options := importer.Options{
+		TableName:   table,
+		Morphemes:   *morphemesPtr,
+		Granularity: granularity,
+		Checkpoint:  progress,
+		Manifest:    manifest,
+		Concurrency: *concurrencyPtr,
+		Retry: retry.Policy{
+			Attempts: *attemptsPtr,
+			Delay:    *backoffPtr,
+			MaxDelay: retry.Default.MaxDelay,
+		},
+		WritesPerSecond: *ratePtr,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	if mode == "wlc" {
 		style := *stylePtr
-		activeParser = parser.CreateWlc(style)
+		if style == corpus.StyleEnglish {
+			fmt.Println("Using English verses.")
+		} else if style == corpus.StyleBoth {
+			fmt.Println("Using Hebrew verses with aligned English references.")
+		} else {
+			fmt.Println("Using Hebrew verses. Specify -style=english for the other mode.")
+		}
+		wlc := corpus.NewWlc(source, style)
+		wlc.Select(selection...)
+		wlc.ContinueOnError(*continuePtr)
+		if formatter != nil {
+			wlc.Parsing(formatter)
+		}
+		wlc.Transliterate(*transliteratePtr)
+		wlc.Concurrency(*concurrencyPtr)
+		err = importer.Wlc(ctx, wlc, sink, options)
 	} else {
-		activeParser = parser.CreateGnt()
+		gnt := corpus.NewGnt(source)
+		gnt.Select(selection...)
+		gnt.ContinueOnError(*continuePtr)
+		if formatter != nil {
+			gnt.Parsing(formatter)
+		}
+		gnt.Concurrency(*concurrencyPtr)
+		err = importer.Gnt(ctx, gnt, sink, options)
+	}
+	if errors.Is(err, context.Canceled) {
+		stop()
+		fmt.Fprintf(os.Stderr, "Interrupted. Progress is saved in %s; run again with -resume to continue.\n", checkpointFile)
+		os.Exit(exitInterrupted)
 	}
-	err = activeParser.Process()
 	if err != nil {
 		util.Errorf(err.Error())
 	}
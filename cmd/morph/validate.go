@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/util"
+)
+
+// validationReport counts the books and words of the source once. For wlc
+// these are the Hebrew tree's; each tree's own counts are in
+// Versifications.
+type validationReport struct {
+	Mode           string              `json:"mode"`
+	Books          int                 `json:"books"`
+	Words          int                 `json:"words"`
+	Versifications []versificationTree `json:"versifications,omitempty"`
+	Valid          bool                `json:"valid"`
+	Problems       corpus.ParseErrors  `json:"problems"`
+}
+
+type versificationTree struct {
+	Style string `json:"style"`
+	Books int    `json:"books"`
+	Words int    `json:"words"`
+}
+
+// add records the outcome of a run, keeping parse errors and returning
+// anything that stopped the run
+func (r *validationReport) add(err error) error {
+	var parseErrors corpus.ParseErrors
+	if errors.As(err, &parseErrors) {
+		r.Problems = append(r.Problems, parseErrors...).Unique()
+		return nil
+	}
+	return err
+}
+
+// validateCommand parses the source strictly without saving anything and
+// writes every problem as a JSON report. It exits 1 when there are
+// problems and 2 when the source can't be read at all.
+func validateCommand(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	modePtr := flags.String("mode", "", "gnt|wlc")
+	booksPtr := flags.String("books", "", "comma-separated books to validate")
+	rangePtr := flags.String("range", "", "references to validate")
+	flags.Parse(args)
+	selection, err := corpus.ParseSelection(*booksPtr, *rangePtr)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	report := &validationReport{Mode: *modePtr, Problems: corpus.ParseErrors{}}
+	if (*modePtr == "wlc" || *modePtr == "gnt") && !selectsCorpus(selection, *modePtr) {
+		util.Errorf("-books and -range select no %s book", *modePtr)
+	}
+	switch *modePtr {
+	case "wlc":
+		//+ the English tree is a separate set of files, so check both
+		for _, style := range []string{corpus.StyleHebrew, corpus.StyleEnglish} {
+			wlc := corpus.NewWlc(getenv("SOURCE", "./morphhb/"), style)
+			wlc.Select(selection...)
+			wlc.ContinueOnError(true)
+			wlc.Strict(true)
+			tree := versificationTree{Style: style}
+			err = report.add(wlc.Books(func(book corpus.WlcBook) error {
+				tree.Books++
+				tree.Words += len(book.Words)
+				return nil
+			}))
+			if err != nil {
+				util.Errorf(err.Error())
+			}
+			report.Versifications = append(report.Versifications, tree)
+		}
+		report.Books = report.Versifications[0].Books
+		report.Words = report.Versifications[0].Words
+	case "gnt":
+		gnt := corpus.NewGnt(getenv("SOURCE", "./morphgnt/"))
+		gnt.Select(selection...)
+		gnt.ContinueOnError(true)
+		gnt.Strict(true)
+		err = report.add(gnt.Books(func(book corpus.GntBook) error {
+			report.Books++
+			report.Words += len(book.Words)
+			return nil
+		}))
+		if err != nil {
+			util.Errorf(err.Error())
+		}
+	default:
+		util.Errorf("-mode is required: gnt|wlc")
+	}
+	report.Valid = len(report.Problems) == 0
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(report)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
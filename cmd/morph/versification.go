@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/util"
+)
+
+func formatReferences(references []corpus.Reference) string {
+	var ids []string
+	for _, r := range references {
+		ids = append(ids, r.OSISID())
+	}
+	if len(ids) == 0 {
+		return "-"
+	}
+	return strings.Join(ids, ",")
+}
+
+// versificationCommand reports every verse that differs between the Hebrew
+// and English versification trees
+func versificationCommand(args []string) {
+	flags := flag.NewFlagSet("versification", flag.ExitOnError)
+	booksPtr := flags.String("books", "", "comma-separated books to compare")
+	rangePtr := flags.String("range", "", "references to compare")
+	jsonPtr := flags.Bool("json", false, "write one JSON object per difference")
+	flags.Parse(args)
+	selection, err := corpus.ParseSelection(*booksPtr, *rangePtr)
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	wlc := corpus.NewWlc(getenv("SOURCE", "./morphhb/"), corpus.StyleBoth)
+	wlc.Select(selection...)
+	counts := make(map[string]int)
+	encoder := json.NewEncoder(os.Stdout)
+	err = wlc.VersificationDifferences(func(book corpus.Book, differences []corpus.VersificationDifference) error {
+		for _, d := range differences {
+			counts[d.Kind]++
+			if *jsonPtr {
+				err := encoder.Encode(d)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			fmt.Printf("%s\t%s\t%s\t%s\n", book.Name, d.Kind, formatReferences(d.Hebrew), formatReferences(d.English))
+		}
+		return nil
+	})
+	if err != nil {
+		util.Errorf(err.Error())
+	}
+	if !*jsonPtr {
+		fmt.Printf("split: %d, merged: %d, renumbered: %d, hebrew-only: %d, english-only: %d\n",
+			counts["split"], counts["merged"], counts["renumbered"], counts["hebrew-only"], counts["english-only"])
+	}
+}
@@ -0,0 +1,397 @@
+// Package corpus parses the Westminster Leningrad Codex (WLC) and MorphGNT
+// texts into words. It has no persistence side effects; callers receive
+// each parsed book and decide what to do with it.
+package corpus
+
+import (
+	"context"
+	"github.com/davidbetz/morph/internal/canon"
+	"github.com/davidbetz/morph/internal/describe"
+	"github.com/davidbetz/morph/internal/greek"
+	"github.com/davidbetz/morph/internal/hebrew"
+	"github.com/davidbetz/morph/internal/models"
+	"github.com/davidbetz/morph/internal/parser"
+	"github.com/davidbetz/morph/internal/versification"
+)
+
+const (
+	// StyleHebrew selects Hebrew versification for the WLC
+	StyleHebrew = "hebrew"
+	// StyleEnglish selects English versification for the WLC
+	StyleEnglish = "english"
+	// StyleBoth parses both trees and gives every word its Hebrew
+	// reference plus the aligned English reference
+	StyleBoth = "both"
+)
+
+const (
+	// ParsingFull spells out every label: Verb Qal Perfect 3rd masculine singular
+	ParsingFull = describe.Full
+	// ParsingCompact uses grammar-book abbreviations: Qal Pf 3ms
+	ParsingCompact = describe.Compact
+	// ParsingLeipzig writes Leipzig-style glosses: QAL.PFV.3SG.M
+	ParsingLeipzig = describe.Leipzig
+)
+
+// Reference locates a word by canonical book number (1-66), chapter, verse
+// and word position. Both corpora derive their verse (BBCCCVVV) and word
+// (BBCCCVVVWWW) IDs from it.
+type Reference = models.Reference
+
+// Book is a book of the 66-book canon with its OSIS ID
+type Book = canon.Book
+
+// Books returns every book of the canon in order
+func Books() []Book {
+	return canon.Books()
+}
+
+// Range is an inclusive span of verses within one book. VerseIDs and
+// WordIDs give the ID bounds to query either corpus with; Corpus tells
+// which corpus the range belongs to.
+type Range = canon.Range
+
+// LookupBook finds a book by full name (I Samuel or 1 Samuel), OSIS ID or
+// common abbreviation
+func LookupBook(name string) (Book, error) {
+	return canon.LookupBook(name)
+}
+
+// ParseReferences parses references such as "Gen 1:1-2:3", "Jn 3:16",
+// "1 Cor 13" or "Ps 23; Isa 53:4-6" into verse ranges
+func ParseReferences(text string) ([]Range, error) {
+	return canon.ParseReferences(text)
+}
+
+// ParseSelection parses a comma-separated list of books and a
+// semicolon-separated list of reference ranges into the ranges to select
+func ParseSelection(books string, ranges string) ([]Range, error) {
+	return canon.ParseSelection(books, ranges)
+}
+
+// ParseError is a problem in the source text with the file, book, chapter,
+// verse, word position and offending code where it was found
+type ParseError = parser.ParseError
+
+// ParseErrors is every problem found when continuing past errors
+type ParseErrors = parser.ParseErrors
+
+// WlcWord is a parsed WLC word
+type WlcWord = models.WlcWord
+
+// HebrewConsonantal reduces Hebrew text to its consonants, as stored in
+// WlcWord.Consonantal, so a search typed without vowels can be matched
+func HebrewConsonantal(text string) string {
+	return hebrew.Consonantal(text)
+}
+
+// HebrewPointed strips cantillation from Hebrew text but keeps its vowel
+// points, as stored in WlcWord.Pointed
+func HebrewPointed(text string) string {
+	return hebrew.Pointed(text)
+}
+
+// HebrewNormalized puts Hebrew text in NFC without morpheme separators, as
+// stored in WlcWord.Normalized
+func HebrewNormalized(text string) string {
+	return hebrew.Normalized(text)
+}
+
+// GreekFold lowercases Greek text and strips its diacritics, as stored in
+// GntWord.Folded, so a search typed without accents can be matched
+func GreekFold(text string) string {
+	return greek.Fold(text)
+}
+
+// GreekBetaCode writes Greek text in Beta Code, as stored in
+// GntWord.BetaCode
+func GreekBetaCode(text string) string {
+	return greek.BetaCode(text)
+}
+
+// GreekTransliterate writes Greek text in SBL transliteration, as stored in
+// GntWord.Transliteration
+func GreekTransliterate(text string) string {
+	return greek.Transliterate(text)
+}
+
+// HebrewTransliterate writes a WLC word in the SBL academic style, as
+// stored in WlcWord.Transliteration
+func HebrewTransliterate(text string) string {
+	return hebrew.Transliterate(text, hebrew.Academic)
+}
+
+// HebrewSimpleTransliterate writes a WLC word in the simple style, as
+// stored in WlcWord.SimpleTransliteration
+func HebrewSimpleTransliterate(text string) string {
+	return hebrew.Transliterate(text, hebrew.Simple)
+}
+
+// WlcMorpheme is one slash-separated segment of a WLC word with its
+// surface text, lemma and morphology aligned
+type WlcMorpheme = models.WlcMorpheme
+
+// WlcMorphology is the decoded morphology of one WLC morpheme
+type WlcMorphology = models.WlcMorphology
+
+// Strongs is a Strong's number with its disambiguation letter. Its String
+// method gives the H-prefixed form such as H1254a.
+type Strongs = models.Strongs
+
+// DecodeWlc decodes an OSHB morphology code such as HVqp3ms into the
+// morphology of each morpheme
+func DecodeWlc(code string) ([]WlcMorphology, error) {
+	return strictWlc().Decode(code)
+}
+
+// EncodeWlc builds the OSHB code for the morphology of each morpheme of a
+// word. It is the inverse of DecodeWlc: every code in the WLC decodes and
+// encodes back to itself.
+func EncodeWlc(morphology ...WlcMorphology) (string, error) {
+	return strictWlc().Encode(morphology)
+}
+
+// ParseWlcDescription reads morphology described in words, such as "Hebrew
+// qal perfect 3ms" or "conjunction / qal wayyiqtol 3ms", or in the
+// key=value form of MorphologyString
+func ParseWlcDescription(text string) ([]WlcMorphology, error) {
+	return strictWlc().ParseDescription(text)
+}
+
+func strictWlc() *parser.Wlc {
+	wlc := parser.CreateWlc(StyleHebrew)
+	wlc.Strict(true)
+	return wlc
+}
+
+// Locale holds label translations for each parsing style, keyed by the
+// English value the parser decodes
+type Locale = describe.Locale
+
+// LoadLocale reads a Locale from a JSON file
+func LoadLocale(filename string) (Locale, error) {
+	return describe.LoadLocale(filename)
+}
+
+// Formatter formats decoded morphology as a readable parsing
+type Formatter = describe.Formatter
+
+// NewFormatter creates a Formatter for ParsingFull, ParsingCompact or
+// ParsingLeipzig. The locale may be nil for English labels; otherwise it
+// must have labels for style.
+func NewFormatter(style string, locale Locale) (*Formatter, error) {
+	return describe.New(style, locale)
+}
+
+// Document holds the words of one verse, chapter or book with their text
+type Document = models.Document
+
+// GntWord is a parsed MorphGNT word
+type GntWord = models.GntWord
+
+// GntMorphology is the decoded morphology of a MorphGNT word
+type GntMorphology = models.GntMorphology
+
+// DecodeGnt decodes a MorphGNT part such as V- and code such as 3AAI-S--
+func DecodeGnt(part string, code string) (GntMorphology, error) {
+	return parser.CreateGnt().Decode(part, code)
+}
+
+// EncodeGnt builds the MorphGNT part and code for a morphology. It is the
+// inverse of DecodeGnt.
+func EncodeGnt(morphology GntMorphology) (string, string, error) {
+	return parser.CreateGnt().Encode(morphology)
+}
+
+// ParseGntDescription reads morphology described in words, such as "verb
+// aorist active indicative 3s" or "noun nsf"
+func ParseGntDescription(text string) (GntMorphology, error) {
+	return parser.CreateGnt().ParseDescription(text)
+}
+
+// WlcBook is a parsed WLC book
+type WlcBook struct {
+	Name  string
+	Words []WlcWord
+}
+
+// GntBook is a parsed MorphGNT book
+type GntBook struct {
+	Name  string
+	Words []GntWord
+}
+
+// Wlc reads the WLC from a source folder containing the hebrew and remapped trees
+type Wlc struct {
+	source    string
+	selection canon.Selection
+	parser    *parser.Wlc
+}
+
+// NewWlc creates a WLC reader for source using StyleHebrew, StyleEnglish
+// or StyleBoth
+func NewWlc(source string, style string) *Wlc {
+	return &Wlc{
+		source: source,
+		parser: parser.CreateWlc(style),
+	}
+}
+
+// Books parses each book in canonical order and passes it to fn. Parsing
+// stops at the first error returned by fn.
+func (c *Wlc) Books(fn func(book WlcBook) error) error {
+	return c.BooksContext(context.Background(), fn)
+}
+
+// BooksContext is Books, stopping before the next book once ctx is
+// cancelled and returning ctx.Err()
+func (c *Wlc) BooksContext(ctx context.Context, fn func(book WlcBook) error) error {
+	return c.parser.Books(ctx, c.source, func(book *parser.WlcBook) error {
+		return fn(WlcBook{Name: book.Name, Words: book.Data})
+	})
+}
+
+// Parsing fills in the parsing of every word and morpheme using formatter
+func (c *Wlc) Parsing(formatter *Formatter) {
+	c.parser.Parsing(formatter)
+}
+
+// Transliterate fills in the SBL academic and simple transliterations of
+// every word and morpheme
+func (c *Wlc) Transliterate(transliterate bool) {
+	c.parser.Transliterate(transliterate)
+}
+
+// Concurrency has Books parse up to n books at once. fn is still called
+// with one book at a time, in order.
+func (c *Wlc) Concurrency(n int) {
+	c.parser.Concurrency(n)
+}
+
+// SkipBooks has Books pass over the books skip returns true for, without
+// parsing them
+func (c *Wlc) SkipBooks(skip func(name string) bool) {
+	c.parser.SkipBooks(skip)
+}
+
+// Select restricts Books to the books and verses within ranges
+func (c *Wlc) Select(ranges ...Range) {
+	c.selection = ranges
+	c.parser.Select(ranges)
+}
+
+// ContinueOnError skips words that fail to parse and has Books return
+// every problem as ParseErrors once all books are read
+func (c *Wlc) ContinueOnError(enabled bool) {
+	c.parser.ContinueOnError(enabled)
+}
+
+// Strict also reports problems parsing otherwise tolerates: letters the
+// morphology grammar doesn't define, surface, lemma and morphology
+// segments that don't line up, empty verses and malformed text
+func (c *Wlc) Strict(enabled bool) {
+	c.parser.Strict(enabled)
+}
+
+// ParseFile parses a single WLC book file
+func (c *Wlc) ParseFile(bookName string, filename string) ([]WlcWord, error) {
+	return c.parser.ParseFileContent(bookName, filename)
+}
+
+// MorphemeRows expands words into one row per morpheme, so prefixed
+// prepositions, articles and suffixes become tokens of their own
+func MorphemeRows(words []WlcWord) []WlcWord {
+	return parser.ExpandMorphemes(words)
+}
+
+// VersificationDifference is a verse that is split, merged, renumbered or
+// present in only one of the Hebrew and English schemes
+type VersificationDifference = versification.Difference
+
+// VersificationDifferences compares the Hebrew and English trees of each
+// selected book and passes the differences to fn
+func (c *Wlc) VersificationDifferences(fn func(book Book, differences []VersificationDifference) error) error {
+	for _, book := range canon.Books()[:canon.OldTestamentBooks] {
+		if !c.selection.IncludesBook(book.Number) {
+			continue
+		}
+		hebrew, english, err := c.parser.ParseBothStyles(c.source, book)
+		if err != nil {
+			return err
+		}
+		pairs := versification.Align(hebrew, english)
+		err = fn(book, versification.Compare(hebrew, english, pairs))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gnt reads MorphGNT from a source folder containing the SBLGNT files
+type Gnt struct {
+	source string
+	parser *parser.Gnt
+}
+
+// NewGnt creates a MorphGNT reader for source
+func NewGnt(source string) *Gnt {
+	return &Gnt{
+		source: source,
+		parser: parser.CreateGnt(),
+	}
+}
+
+// Books parses each book and passes it to fn. Parsing stops at the first
+// error returned by fn.
+func (c *Gnt) Books(fn func(book GntBook) error) error {
+	return c.BooksContext(context.Background(), fn)
+}
+
+// BooksContext is Books, stopping before the next book once ctx is
+// cancelled and returning ctx.Err()
+func (c *Gnt) BooksContext(ctx context.Context, fn func(book GntBook) error) error {
+	return c.parser.Books(ctx, c.source, func(book *parser.GntBook) error {
+		return fn(GntBook{Name: book.Name, Words: book.Data})
+	})
+}
+
+// Parsing fills in the parsing of every word using formatter
+func (c *Gnt) Parsing(formatter *Formatter) {
+	c.parser.Parsing(formatter)
+}
+
+// Concurrency has Books parse up to n books at once. fn is still called
+// with one book at a time, in order.
+func (c *Gnt) Concurrency(n int) {
+	c.parser.Concurrency(n)
+}
+
+// SkipBooks has Books pass over the books skip returns true for, without
+// parsing them
+func (c *Gnt) SkipBooks(skip func(name string) bool) {
+	c.parser.SkipBooks(skip)
+}
+
+// Select restricts Books to the books and verses within ranges
+func (c *Gnt) Select(ranges ...Range) {
+	c.parser.Select(ranges)
+}
+
+// ContinueOnError skips words that fail to parse and has Books return
+// every problem as ParseErrors once all books are read
+func (c *Gnt) ContinueOnError(enabled bool) {
+	c.parser.ContinueOnError(enabled)
+}
+
+// Strict also reports problems parsing otherwise tolerates: letters the
+// morphology grammar doesn't define, surface, lemma and morphology
+// segments that don't line up, empty verses and malformed text
+func (c *Gnt) Strict(enabled bool) {
+	c.parser.Strict(enabled)
+}
+
+// ParseFile parses a single MorphGNT book file
+func (c *Gnt) ParseFile(filename string) ([]GntWord, error) {
+	return c.parser.ParseFileContent(filename)
+}
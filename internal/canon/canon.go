@@ -0,0 +1,141 @@
+package canon
+
+import (
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+const (
+	// OldTestamentBooks is the number of books in the WLC
+	OldTestamentBooks = 39
+	// BookCount is the number of books in the canon
+	BookCount = 66
+)
+
+// Book is a book of the 66-book canon
+type Book struct {
+	Number int
+	OSIS   string
+	Name   string
+	// Chapters is the number of chapters in whichever of the Hebrew and
+	// English versifications has more, so Joel and Malachi have 4
+	Chapters int
+}
+
+// Filename is the book's name as used for source and output files
+func (b Book) Filename() string {
+	return strings.ToLower(strings.Replace(b.Name, " ", "", -1))
+}
+
+// Testament is wlc for the Old Testament and gnt for the New Testament
+func (b Book) Testament() string {
+	if b.Number > OldTestamentBooks {
+		return "gnt"
+	}
+	return "wlc"
+}
+
+var books = []Book{
+	{1, "Gen", "Genesis", 50},
+	{2, "Exod", "Exodus", 40},
+	{3, "Lev", "Leviticus", 27},
+	{4, "Num", "Numbers", 36},
+	{5, "Deut", "Deuteronomy", 34},
+	{6, "Josh", "Joshua", 24},
+	{7, "Judg", "Judges", 21},
+	{8, "Ruth", "Ruth", 4},
+	{9, "1Sam", "I Samuel", 31},
+	{10, "2Sam", "II Samuel", 24},
+	{11, "1Kgs", "I Kings", 22},
+	{12, "2Kgs", "II Kings", 25},
+	{13, "1Chr", "I Chronicles", 29},
+	{14, "2Chr", "II Chronicles", 36},
+	{15, "Ezra", "Ezra", 10},
+	{16, "Neh", "Nehemiah", 13},
+	{17, "Esth", "Esther", 10},
+	{18, "Job", "Job", 42},
+	{19, "Ps", "Psalms", 150},
+	{20, "Prov", "Proverbs", 31},
+	{21, "Eccl", "Ecclesiastes", 12},
+	{22, "Song", "Song of Solomon", 8},
+	{23, "Isa", "Isaiah", 66},
+	{24, "Jer", "Jeremiah", 52},
+	{25, "Lam", "Lamentations", 5},
+	{26, "Ezek", "Ezekiel", 48},
+	{27, "Dan", "Daniel", 12},
+	{28, "Hos", "Hosea", 14},
+	{29, "Joel", "Joel", 4},
+	{30, "Amos", "Amos", 9},
+	{31, "Obad", "Obadiah", 1},
+	{32, "Jonah", "Jonah", 4},
+	{33, "Mic", "Micah", 7},
+	{34, "Nah", "Nahum", 3},
+	{35, "Hab", "Habakkuk", 3},
+	{36, "Zeph", "Zephaniah", 3},
+	{37, "Hag", "Haggai", 2},
+	{38, "Zech", "Zechariah", 14},
+	{39, "Mal", "Malachi", 4},
+	{40, "Matt", "Matthew", 28},
+	{41, "Mark", "Mark", 16},
+	{42, "Luke", "Luke", 24},
+	{43, "John", "John", 21},
+	{44, "Acts", "Acts", 28},
+	{45, "Rom", "Romans", 16},
+	{46, "1Cor", "1 Corinthians", 16},
+	{47, "2Cor", "2 Corinthians", 13},
+	{48, "Gal", "Galatians", 6},
+	{49, "Eph", "Ephesians", 6},
+	{50, "Phil", "Philippians", 4},
+	{51, "Col", "Colossians", 4},
+	{52, "1Thess", "1 Thessalonians", 5},
+	{53, "2Thess", "2 Thessalonians", 3},
+	{54, "1Tim", "1 Timothy", 6},
+	{55, "2Tim", "2 Timothy", 4},
+	{56, "Titus", "Titus", 3},
+	{57, "Phlm", "Philemon", 1},
+	{58, "Heb", "Hebrews", 13},
+	{59, "Jas", "James", 5},
+	{60, "1Pet", "1 Peter", 5},
+	{61, "2Pet", "2 Peter", 3},
+	{62, "1John", "1 John", 5},
+	{63, "2John", "2 John", 1},
+	{64, "3John", "3 John", 1},
+	{65, "Jude", "Jude", 1},
+	{66, "Rev", "Revelation", 22},
+}
+
+// Books returns every book in canonical order
+func Books() []Book {
+	return append([]Book(nil), books...)
+}
+
+// BookByNumber returns the book numbered 1-66
+func BookByNumber(number int) (Book, bool) {
+	if number < 1 || number > len(books) {
+		return Book{}, false
+	}
+	return books[number-1], true
+}
+
+// NewReference creates a reference to a word; word 0 refers to the whole verse
+func NewReference(book int, chapter int, verse int, word int) models.Reference {
+	b, _ := BookByNumber(book)
+	return models.Reference{
+		Book:    book,
+		BookID:  b.OSIS,
+		Chapter: chapter,
+		Verse:   verse,
+		Word:    word,
+	}
+}
+
+// BookByName returns the book with the given name, ignoring case
+func BookByName(name string) (Book, bool) {
+	for _, b := range books {
+		if strings.EqualFold(b.Name, name) {
+			return b, true
+		}
+	}
+	return Book{}, false
+}
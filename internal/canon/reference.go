@@ -0,0 +1,309 @@
+package canon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// lastVerse stands in for the end of a chapter or book; there is no
+// versification table, so open ranges run to the highest possible number
+const lastVerse = 999
+
+// abbreviations that prefix matching on the book name can't produce
+var abbreviations = map[string]int{
+	"gn": 1, "ge": 1,
+	"ex": 2,
+	"lv": 3, "le": 3,
+	"nm": 4, "nu": 4,
+	"dt":  5,
+	"jos": 6, "jsh": 6,
+	"jdg": 7, "jdgs": 7, "jg": 7,
+	"rth": 8, "ru": 8,
+	"1sm": 9, "1sa": 9,
+	"2sm": 10, "2sa": 10,
+	"1kgs": 11, "1kg": 11, "1ki": 11,
+	"2kgs": 12, "2kg": 12, "2ki": 12,
+	"1chr": 13, "1ch": 13,
+	"2chr": 14, "2ch": 14,
+	"ezr": 15,
+	"ne":  16,
+	"es":  17,
+	"jb":  18,
+	"ps":  19, "psa": 19, "pss": 19, "psalm": 19,
+	"prv": 20, "pr": 20,
+	"ec": 21, "qoh": 21, "qoheleth": 21,
+	"sng": 22, "sos": 22, "songofsongs": 22, "canticles": 22, "cant": 22,
+	"is":  23,
+	"jr":  24,
+	"la":  25,
+	"ezk": 26, "eze": 26,
+	"dn": 27, "da": 27,
+	"ho":  28,
+	"jl":  29,
+	"am":  30,
+	"ob":  31,
+	"jnh": 32, "jon": 32,
+	"mi":  33,
+	"na":  34,
+	"hb":  35,
+	"zep": 36, "zp": 36,
+	"hg":  37,
+	"zec": 38, "zc": 38,
+	"ml": 39,
+	"mt": 40,
+	"mk": 41, "mrk": 41,
+	"lk": 42, "luk": 42,
+	"jn": 43, "jhn": 43,
+	"ac": 44,
+	"rm": 45, "ro": 45,
+	"1co":   46,
+	"2co":   47,
+	"ga":    48,
+	"ephes": 49,
+	"php":   50, "phil": 50, "pp": 50,
+	"cl":  51,
+	"1th": 52,
+	"2th": 53,
+	"1ti": 54, "1tm": 54,
+	"2ti": 55, "2tm": 55,
+	"ti": 56, "tit": 56,
+	"phm": 57, "philem": 57, "pm": 57,
+	"jm":  59,
+	"1pe": 60, "1pt": 60,
+	"2pe": 61, "2pt": 61,
+	"1jn": 62, "1jhn": 62, "1jo": 62,
+	"2jn": 63, "2jhn": 63, "2jo": 63,
+	"3jn": 64, "3jhn": 64, "3jo": 64,
+	"jud": 65, "jd": 65,
+	"re": 66, "rv": 66, "apoc": 66, "apocalypse": 66,
+}
+
+// singleChapter lists books with one chapter, where a single number means a verse
+var singleChapter = map[int]bool{31: true, 57: true, 63: true, 64: true, 65: true}
+
+var ordinalPrefixes = []struct {
+	prefix string
+	digit  string
+}{
+	{"iii", "3"},
+	{"ii", "2"},
+	{"i", "1"},
+	{"third", "3"},
+	{"second", "2"},
+	{"first", "1"},
+	{"3rd", "3"},
+	{"2nd", "2"},
+	{"1st", "1"},
+}
+
+var (
+	itemRe    = regexp.MustCompile(`^((?:[1-3]\s*)?\pL[^\d]*?)\s*(\d.*)?$`)
+	sectionRe = regexp.MustCompile(`^(\d+)(?:[:.](\d+))?(?:\s*[-–—]\s*(\d+)(?:[:.](\d+))?)?$`)
+)
+
+// normalizeBookName lowercases a book name, strips spaces and periods and
+// turns a leading roman or spelled ordinal into a digit: "I Samuel" and
+// "1 Sam." both become 1samuel and 1sam
+func normalizeBookName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	fields := strings.Fields(strings.Replace(name, ".", " ", -1))
+	if len(fields) > 1 {
+		for _, p := range ordinalPrefixes {
+			if fields[0] == p.prefix {
+				fields[0] = p.digit
+				break
+			}
+		}
+	}
+	return strings.Join(fields, "")
+}
+
+// LookupBook finds a book by full name, OSIS ID or common abbreviation.
+// Unambiguous prefixes of a book name are accepted too.
+func LookupBook(name string) (Book, error) {
+	key := normalizeBookName(name)
+	if len(key) == 0 {
+		return Book{}, fmt.Errorf("missing book name")
+	}
+	for _, b := range books {
+		if key == normalizeBookName(b.Name) || key == strings.ToLower(b.OSIS) {
+			return b, nil
+		}
+	}
+	if number, ok := abbreviations[key]; ok {
+		return books[number-1], nil
+	}
+	var matches []Book
+	for _, b := range books {
+		if strings.HasPrefix(normalizeBookName(b.Name), key) {
+			matches = append(matches, b)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		var names []string
+		for _, b := range matches {
+			names = append(names, b.Name)
+		}
+		return Book{}, fmt.Errorf("ambiguous book %q: %s", name, strings.Join(names, ", "))
+	}
+	return Book{}, fmt.Errorf("unknown book %q", name)
+}
+
+// Range is an inclusive span of verses within one book
+type Range struct {
+	Start models.Reference
+	End   models.Reference
+}
+
+// VerseIDs returns the first and last verse IDs of the range
+func (r Range) VerseIDs() (string, string) {
+	return r.Start.VerseID(), r.End.VerseID()
+}
+
+// WordIDs returns the lowest and highest word IDs the range can contain
+func (r Range) WordIDs() (int64, int64) {
+	start := r.Start
+	start.Word = 0
+	end := r.End
+	end.Word = lastVerse
+	return start.WordID(), end.WordID()
+}
+
+// Corpus is wlc for Old Testament ranges and gnt for New Testament ranges
+func (r Range) Corpus() string {
+	b, _ := BookByNumber(r.Start.Book)
+	return b.Testament()
+}
+
+// Contains reports whether the word falls within the range
+func (r Range) Contains(reference models.Reference) bool {
+	low, high := r.WordIDs()
+	id := reference.WordID()
+	return id >= low && id <= high
+}
+
+// String formats the range in OSIS, leaving off the chapters or verses an
+// open range runs to the end of: Matt, Gen.1-Gen.3, Ps.23
+func (r Range) String() string {
+	if r.Start.Chapter == 1 && r.Start.Verse == 1 && r.End.Chapter == lastVerse {
+		return r.Start.BookID
+	}
+	start := r.Start.OSISID()
+	end := r.End.OSISID()
+	if r.End.Verse == lastVerse {
+		end = fmt.Sprintf("%s.%d", r.End.BookID, r.End.Chapter)
+		if r.Start.Verse == 1 {
+			start = fmt.Sprintf("%s.%d", r.Start.BookID, r.Start.Chapter)
+		}
+	}
+	if start == end {
+		return start
+	}
+	return start + "-" + end
+}
+
+func newRange(book int, startChapter int, startVerse int, endChapter int, endVerse int) Range {
+	return Range{
+		Start: NewReference(book, startChapter, startVerse, 0),
+		End:   NewReference(book, endChapter, endVerse, 0),
+	}
+}
+
+func atoi(text string) int {
+	n, _ := strconv.Atoi(text)
+	return n
+}
+
+// ParseReferences parses references such as "Gen 1:1-2:3", "Jn 3:16, 18",
+// "1 Cor 13" or "Ps 23; Isa 53:4-6" into verse ranges. Items separated by
+// semicolons may omit the book to continue the previous one; sections
+// separated by commas continue the previous chapter when it named a verse.
+func ParseReferences(text string) ([]Range, error) {
+	var ranges []Range
+	var book Book
+	for _, item := range strings.Split(text, ";") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		numbers := item
+		if match := itemRe.FindStringSubmatch(item); match != nil {
+			b, err := LookupBook(match[1])
+			if err != nil {
+				return nil, err
+			}
+			book = b
+			numbers = match[2]
+		} else if book.Number == 0 {
+			return nil, fmt.Errorf("invalid reference %q: missing book", item)
+		}
+		if len(strings.TrimSpace(numbers)) == 0 {
+			ranges = append(ranges, newRange(book.Number, 1, 1, lastVerse, lastVerse))
+			continue
+		}
+		chapter := 0
+		verseMode := false
+		for _, section := range strings.Split(numbers, ",") {
+			section = strings.TrimSpace(section)
+			match := sectionRe.FindStringSubmatch(section)
+			if match == nil {
+				return nil, fmt.Errorf("invalid reference %q", item)
+			}
+			a, b, c, d := atoi(match[1]), atoi(match[2]), atoi(match[3]), atoi(match[4])
+			var r Range
+			switch {
+			case len(match[2]) > 0 && len(match[4]) > 0:
+				r = newRange(book.Number, a, b, c, d)
+				chapter = c
+				verseMode = true
+			case len(match[2]) > 0 && len(match[3]) > 0:
+				r = newRange(book.Number, a, b, a, c)
+				chapter = a
+				verseMode = true
+			case len(match[2]) > 0:
+				r = newRange(book.Number, a, b, a, b)
+				chapter = a
+				verseMode = true
+			case len(match[4]) > 0:
+				r = newRange(book.Number, a, 1, c, d)
+				chapter = c
+				verseMode = true
+			case verseMode || singleChapter[book.Number]:
+				if chapter == 0 {
+					chapter = 1
+				}
+				end := a
+				if len(match[3]) > 0 {
+					end = c
+				}
+				r = newRange(book.Number, chapter, a, chapter, end)
+				verseMode = true
+			default:
+				end := a
+				if len(match[3]) > 0 {
+					end = c
+				}
+				r = newRange(book.Number, a, 1, end, lastVerse)
+				chapter = end
+			}
+			if r.Start.Chapter < 1 || r.Start.Verse < 1 || r.End.Chapter < 1 || r.End.Verse < 1 {
+				return nil, fmt.Errorf("invalid reference %q: chapters and verses start at 1", item)
+			}
+			if r.End.Chapter > book.Chapters {
+				return nil, fmt.Errorf("invalid reference %q: %s ends at chapter %d", item, book.Name, book.Chapters)
+			}
+			if r.Start.WordID() > r.End.WordID() {
+				return nil, fmt.Errorf("invalid reference %q: range ends before it starts", item)
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges, nil
+}
@@ -0,0 +1,144 @@
+package canon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupBook(t *testing.T) {
+	tests := []struct {
+		name string
+		osis string
+		err  string
+	}{
+		{"Genesis", "Gen", ""},
+		{"gen", "Gen", ""},
+		{"Gn", "Gen", ""},
+		{"1 Sam.", "1Sam", ""},
+		{"I Samuel", "1Sam", ""},
+		{"First Samuel", "1Sam", ""},
+		{"2nd Kings", "2Kgs", ""},
+		{"Ps", "Ps", ""},
+		{"Psalm", "Ps", ""},
+		{"Song of Songs", "Song", ""},
+		{"Qoh", "Eccl", ""},
+		{"Mt", "Matt", ""},
+		{"Jn", "John", ""},
+		{"1 Jn", "1John", ""},
+		{"Philem", "Phlm", ""},
+		{"Rev", "Rev", ""},
+		{"apocalypse", "Rev", ""},
+		{"Hab", "Hab", ""},
+		{"Ju", "", "ambiguous book"},
+		{"Tobit", "", "unknown book"},
+		{" ", "", "missing book name"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			book, err := LookupBook(test.name)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %s, %v; want error containing %q", book.OSIS, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if book.OSIS != test.osis {
+				t.Errorf("got %s, want %s", book.OSIS, test.osis)
+			}
+		})
+	}
+}
+
+func TestParseReferences(t *testing.T) {
+	tests := []struct {
+		text   string
+		ranges string
+		err    string
+	}{
+		{"Gen 1:1", "Gen.1.1", ""},
+		{"Gen 1:1-2:3", "Gen.1.1-Gen.2.3", ""},
+		{"Gen 1:1–5", "Gen.1.1-Gen.1.5", ""},
+		{"Jn 3:16, 18", "John.3.16; John.3.18", ""},
+		{"1 Cor 13", "1Cor.13", ""},
+		{"Gen 1-3", "Gen.1-Gen.3", ""},
+		{"Gen 1-2:3", "Gen.1.1-Gen.2.3", ""},
+		{"Gen 1, 3", "Gen.1; Gen.3", ""},
+		{"Ps 23; Isa 53:4-6", "Ps.23; Isa.53.4-Isa.53.6", ""},
+		{"Isa 53:4; 6", "Isa.53.4; Isa.6", ""},
+		{"Matt", "Matt", ""},
+		{"Jude 3", "Jude.1.3", ""},
+		{"Jude 3-5", "Jude.1.3-Jude.1.5", ""},
+		{"Mal 4", "Mal.4", ""},
+		{"Ps 150", "Ps.150", ""},
+		{"", "", ""},
+		{"Gen 0:3", "", "chapters and verses start at 1"},
+		{"Gen 1:0", "", "chapters and verses start at 1"},
+		{"Gen 0", "", "chapters and verses start at 1"},
+		{"Ps 151", "", "Psalms ends at chapter 150"},
+		{"Gen 50-51", "", "Genesis ends at chapter 50"},
+		{"Jude 2:1", "", "Jude ends at chapter 1"},
+		{"Gen 3-1", "", "range ends before it starts"},
+		{"Gen 1:5-3", "", "range ends before it starts"},
+		{"Gen 1:a", "", "invalid reference"},
+		{"3:16", "", "missing book"},
+		{"Hezekiah 1", "", "unknown book"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			ranges, err := ParseReferences(test.text)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, %v; want error containing %q", ranges, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := Selection(ranges).String(); got != test.ranges && !(len(ranges) == 0 && len(test.ranges) == 0) {
+				t.Errorf("got %s, want %s", got, test.ranges)
+			}
+		})
+	}
+}
+
+func TestRangeWordIDs(t *testing.T) {
+	ranges, err := ParseReferences("Gen 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, high := ranges[0].WordIDs()
+	if low != 1001001000 || high != 1001999999 {
+		t.Errorf("got %d-%d, want 1001001000-1001999999", low, high)
+	}
+	if !ranges[0].Contains(NewReference(1, 1, 31, 7)) || ranges[0].Contains(NewReference(1, 2, 1, 1)) {
+		t.Error("Gen 1 should contain Gen 1:31 and not Gen 2:1")
+	}
+}
+
+func TestSelection(t *testing.T) {
+	selection, err := ParseSelection("Ruth, Matt", "Gen 1:1-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selection.String(); got != "Ruth; Matt; Gen.1.1-Gen.1.3" {
+		t.Errorf("got %s", got)
+	}
+	for _, test := range []struct {
+		book int
+		want bool
+	}{{1, true}, {2, false}, {8, true}, {40, true}, {41, false}} {
+		if got := selection.IncludesBook(test.book); got != test.want {
+			t.Errorf("IncludesBook(%d) = %v, want %v", test.book, got, test.want)
+		}
+	}
+	if !selection.Includes(NewReference(1, 1, 2, 5)) || selection.Includes(NewReference(1, 1, 4, 1)) {
+		t.Error("Gen 1:1-3 should include Gen 1:2 and not Gen 1:4")
+	}
+	if got := Selection(nil).String(); got != "all" || !Selection(nil).IncludesBook(66) {
+		t.Errorf("an empty selection should select everything, got %s", got)
+	}
+}
@@ -0,0 +1,69 @@
+package canon
+
+import (
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// Selection restricts which books and verses are read. An empty Selection
+// selects everything.
+type Selection []Range
+
+// ParseSelection builds a Selection from a comma-separated list of books
+// and a semicolon-separated list of reference ranges
+func ParseSelection(books string, ranges string) (Selection, error) {
+	var selection Selection
+	for _, name := range strings.Split(books, ",") {
+		if len(strings.TrimSpace(name)) == 0 {
+			continue
+		}
+		r, err := ParseReferences(name)
+		if err != nil {
+			return nil, err
+		}
+		selection = append(selection, r...)
+	}
+	r, err := ParseReferences(ranges)
+	if err != nil {
+		return nil, err
+	}
+	return append(selection, r...), nil
+}
+
+// IncludesBook reports whether any part of the book is selected
+func (s Selection) IncludesBook(number int) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if number >= r.Start.Book && number <= r.End.Book {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes reports whether the word is selected
+func (s Selection) Includes(reference models.Reference) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, r := range s {
+		if r.Contains(reference) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Selection) String() string {
+	if len(s) == 0 {
+		return "all"
+	}
+	var ranges []string
+	for _, r := range s {
+		ranges = append(ranges, r.String())
+	}
+	return strings.Join(ranges, "; ")
+}
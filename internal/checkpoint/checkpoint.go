@@ -0,0 +1,203 @@
+// Package checkpoint records which books and partitions each sink has
+// saved, so an interrupted import can resume where it stopped, and writes
+// a manifest of the run once it completes.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Run identifies an import. A checkpoint only resumes the run it was
+// written for, since partition indexes mean nothing for other options.
+type Run struct {
+	Mode    string            `json:"mode"`
+	Table   string            `json:"table"`
+	Sinks   []string          `json:"sinks"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// Book is the progress of one sink through one book
+type Book struct {
+	Partitions int  `json:"partitions"`
+	Records    int  `json:"records"`
+	Done       bool `json:"done"`
+}
+
+// State is what the checkpoint and manifest files hold
+type State struct {
+	Run      Run                         `json:"run"`
+	Started  time.Time                   `json:"started"`
+	Resumed  []time.Time                 `json:"resumed,omitempty"`
+	Finished *time.Time                  `json:"finished,omitempty"`
+	Sinks    map[string]map[string]*Book `json:"sinks"`
+}
+
+// Checkpoint saves progress to a file after every partition. A nil
+// Checkpoint records nothing and skips nothing.
+type Checkpoint struct {
+	mu       sync.Mutex
+	filename string
+	state    State
+}
+
+// Open starts a checkpoint for run in filename. With resume, the progress
+// already in filename is kept; without it, or when there's no file, the
+// run starts from the beginning.
+func Open(filename string, run Run, resume bool) (*Checkpoint, error) {
+	if len(run.Options) == 0 {
+		run.Options = nil
+	}
+	c := &Checkpoint{
+		filename: filename,
+		state: State{
+			Run:     run,
+			Started: time.Now().UTC(),
+			Sinks:   make(map[string]map[string]*Book),
+		},
+	}
+	if resume {
+		data, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("No checkpoint at %s; starting from the beginning.\n", filename)
+			return c, c.save()
+		}
+		if err != nil {
+			return nil, err
+		}
+		var state State
+		err = json.Unmarshal(data, &state)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err.Error())
+		}
+		if !reflect.DeepEqual(state.Run, run) {
+			return nil, fmt.Errorf("%s is a checkpoint for a different run; remove it or run with the same options", filename)
+		}
+		state.Resumed = append(state.Resumed, time.Now().UTC())
+		if state.Sinks == nil {
+			state.Sinks = make(map[string]map[string]*Book)
+		}
+		c.state = state
+		fmt.Printf("Resuming from %s.\n", filename)
+	}
+	return c, c.save()
+}
+
+func (c *Checkpoint) book(sink string, book string) *Book {
+	books, ok := c.state.Sinks[sink]
+	if !ok {
+		books = make(map[string]*Book)
+		c.state.Sinks[sink] = books
+	}
+	progress, ok := books[book]
+	if !ok {
+		progress = &Book{}
+		books[book] = progress
+	}
+	return progress
+}
+
+// Partitions is the number of partitions of book sink has saved
+func (c *Checkpoint) Partitions(sink string, book string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.book(sink, book).Partitions
+}
+
+// Done reports whether sink has saved all of book
+func (c *Checkpoint) Done(sink string, book string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.book(sink, book).Done
+}
+
+// Finished reports whether every sink of the run has saved all of book
+func (c *Checkpoint) Finished(book string) bool {
+	if c == nil {
+		return false
+	}
+	for _, sink := range c.state.Run.Sinks {
+		if !c.Done(sink, book) {
+			return false
+		}
+	}
+	return true
+}
+
+// SavePartition records that sink saved the partition of book at index,
+// holding records records
+func (c *Checkpoint) SavePartition(sink string, book string, index int, records int) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	progress := c.book(sink, book)
+	if index+1 > progress.Partitions {
+		progress.Partitions = index + 1
+	}
+	progress.Records += records
+	return c.save()
+}
+
+// SaveBook records that sink saved all of book
+func (c *Checkpoint) SaveBook(sink string, book string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.book(sink, book).Done = true
+	return c.save()
+}
+
+// Complete writes the manifest of the finished run to manifest and
+// removes the checkpoint, so the next run starts fresh
+func (c *Checkpoint) Complete(manifest string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	finished := time.Now().UTC()
+	c.state.Finished = &finished
+	err := write(manifest, c.state)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(c.filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (c *Checkpoint) save() error {
+	return write(c.filename, c.state)
+}
+
+// write replaces filename with state in one step, so an interrupted write
+// never leaves half a checkpoint
+func write(filename string, state State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporary := filename + ".tmp"
+	err = os.WriteFile(temporary, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(temporary, filename)
+}
@@ -0,0 +1,197 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var run = Run{Mode: "wlc", Table: "wlc", Sinks: []string{"json", "print"}, Options: map[string]string{"parsing": "full"}}
+
+func TestResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	c, err := Open(filename, run, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, step := range []error{
+		c.SavePartition("json", "Genesis", 0, 100),
+		c.SavePartition("json", "Genesis", 1, 100),
+		c.SavePartition("print", "Genesis", 0, 50),
+		c.SaveBook("json", "Exodus"),
+	} {
+		if step != nil {
+			t.Fatal(step)
+		}
+	}
+
+	resumed, err := Open(filename, run, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sink       string
+		book       string
+		partitions int
+		done       bool
+	}{
+		{"json", "Genesis", 2, false},
+		{"print", "Genesis", 1, false},
+		{"json", "Exodus", 0, true},
+		{"print", "Exodus", 0, false},
+		{"json", "Leviticus", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.sink+" "+test.book, func(t *testing.T) {
+			if got := resumed.Partitions(test.sink, test.book); got != test.partitions {
+				t.Errorf("Partitions = %d, want %d", got, test.partitions)
+			}
+			if got := resumed.Done(test.sink, test.book); got != test.done {
+				t.Errorf("Done = %v, want %v", got, test.done)
+			}
+		})
+	}
+	if len(resumed.state.Resumed) != 1 {
+		t.Errorf("got %d resumptions, want 1", len(resumed.state.Resumed))
+	}
+	if got := resumed.state.Sinks["json"]["Genesis"].Records; got != 200 {
+		t.Errorf("got %d records, want 200", got)
+	}
+
+	//+ without -resume the saved progress is dropped
+	fresh, err := Open(filename, run, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fresh.Partitions("json", "Genesis"); got != 0 {
+		t.Errorf("a fresh run starts with %d partitions", got)
+	}
+}
+
+func TestSavePartition(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "checkpoint.json"), run, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//+ partitions saved out of order never move the count backwards
+	for _, index := range []int{0, 2, 1} {
+		if err = c.SavePartition("json", "Ruth", index, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.Partitions("json", "Ruth"); got != 3 {
+		t.Errorf("got %d partitions, want 3", got)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	other := run
+	other.Sinks = []string{"json"}
+	tests := []struct {
+		name    string
+		content string
+		run     Run
+		err     string
+	}{
+		{"missing", "", run, ""},
+		{"same run", `{"run": {"mode": "wlc", "table": "wlc", "sinks": ["json", "print"], "options": {"parsing": "full"}}}`, run, ""},
+		{"no sinks yet", `{"run": {"mode": "wlc", "table": "wlc", "sinks": ["json"]}, "sinks": null}`, Run{Mode: "wlc", Table: "wlc", Sinks: []string{"json"}, Options: map[string]string{}}, ""},
+		{"different run", `{"run": {"mode": "wlc", "table": "wlc", "sinks": ["json", "print"], "options": {"parsing": "full"}}}`, other, "is a checkpoint for a different run"},
+		{"not JSON", `{"run": `, run, "unexpected end of JSON input"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "checkpoint.json")
+			if len(test.content) > 0 {
+				err := os.WriteFile(filename, []byte(test.content), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := Open(filename, test.run, true)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v; want error containing %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err = c.SavePartition("json", "Ruth", 0, 10); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = os.Stat(filename); err != nil {
+				t.Errorf("checkpoint not written: %s", err)
+			}
+		})
+	}
+}
+
+func TestFinished(t *testing.T) {
+	c, err := Open(filepath.Join(t.TempDir(), "checkpoint.json"), run, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Finished("Ruth") {
+		t.Error("no sink has saved Ruth")
+	}
+	if err = c.SaveBook("json", "Ruth"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Finished("Ruth") {
+		t.Error("print hasn't saved Ruth")
+	}
+	if err = c.SaveBook("print", "Ruth"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Finished("Ruth") {
+		t.Error("every sink has saved Ruth")
+	}
+}
+
+func TestComplete(t *testing.T) {
+	folder := t.TempDir()
+	filename := filepath.Join(folder, "checkpoint.json")
+	manifest := filepath.Join(folder, "manifest.json")
+	c, err := Open(filename, run, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SaveBook("json", "Ruth"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Complete(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the checkpoint is still there: %v", err)
+	}
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state State
+	if err = json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Finished == nil || state.Finished.Before(state.Started) || !state.Sinks["json"]["Ruth"].Done {
+		t.Errorf("got manifest %s", data)
+	}
+	if _, err = os.Stat(manifest + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the temporary file is still there: %v", err)
+	}
+}
+
+func TestNil(t *testing.T) {
+	var c *Checkpoint
+	if c.Partitions("json", "Ruth") != 0 || c.Done("json", "Ruth") || c.Finished("Ruth") {
+		t.Error("a nil checkpoint should skip nothing")
+	}
+	if c.SavePartition("json", "Ruth", 0, 10) != nil || c.SaveBook("json", "Ruth") != nil || c.Complete("manifest.json") != nil {
+		t.Error("a nil checkpoint should record nothing")
+	}
+}
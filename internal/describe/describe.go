@@ -0,0 +1,225 @@
+// Package describe formats decoded morphology as a readable parsing, such
+// as "Verb Qal Perfect 3rd masculine singular", "Qal Pf 3ms" or the
+// Leipzig-style gloss "QAL.PFV.3SG.M".
+package describe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+const (
+	// Full spells out every label: Verb Qal Perfect 3rd masculine singular
+	Full = "full"
+	// Compact uses grammar-book abbreviations: Qal Pf 3ms
+	Compact = "compact"
+	// Leipzig writes glosses in the Leipzig style: QAL.PFV.3SG.M
+	Leipzig = "leipzig"
+)
+
+// Styles lists the parsing styles
+var Styles = []string{Full, Compact, Leipzig}
+
+// Locale holds label translations for each style, keyed by the English
+// value the parser decodes, e.g. {"full": {"perfect (qatal)": "Perfekt"}}.
+// Labels a locale doesn't translate fall back to English.
+type Locale map[string]map[string]string
+
+// LoadLocale reads a locale from a JSON file
+func LoadLocale(filename string) (Locale, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var locale Locale
+	err = json.Unmarshal(data, &locale)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err.Error())
+	}
+	for style := range locale {
+		if _, ok := labels[style]; !ok {
+			return nil, fmt.Errorf("%s: unknown style %q: %s", filename, style, strings.Join(Styles, "|"))
+		}
+	}
+	return locale, nil
+}
+
+// Formatter formats morphology in one style
+type Formatter struct {
+	style  string
+	locale map[string]string
+}
+
+// New creates a Formatter for style, translating labels with locale,
+// which may be nil. A locale without labels for style is rejected rather
+// than leaving every label in English.
+func New(style string, locale Locale) (*Formatter, error) {
+	if _, ok := labels[style]; !ok {
+		return nil, fmt.Errorf("unknown parsing style %q: %s", style, strings.Join(Styles, "|"))
+	}
+	if locale != nil && len(locale[style]) == 0 {
+		var covered []string
+		for _, s := range Styles {
+			if len(locale[s]) > 0 {
+				covered = append(covered, s)
+			}
+		}
+		return nil, fmt.Errorf("the locale doesn't translate the %s style, only %s", style, strings.Join(covered, "|"))
+	}
+	return &Formatter{style: style, locale: locale[style]}, nil
+}
+
+// kind says which field a value came from. The full style capitalizes
+// every kind up to conjugationKind except typeKind.
+type kind int
+
+const (
+	partKind kind = iota
+	typeKind
+	stemKind
+	tenseKind
+	voiceKind
+	moodKind
+	conjugationKind
+	caseKind
+	personKind
+	genderKind
+	numberKind
+	stateKind
+	degreeKind
+)
+
+type field struct {
+	kind  kind
+	value string
+}
+
+func (f *Formatter) label(field field) string {
+	label, ok := f.locale[field.value]
+	if !ok {
+		label, ok = labels[f.style][field.value]
+	}
+	if !ok {
+		label = field.value
+		if i := strings.Index(label, " ("); i > 0 {
+			label = label[:i]
+		}
+		if f.style == Leipzig {
+			label = strings.ToUpper(strings.Replace(label, " ", ".", -1))
+		}
+	}
+	if f.style == Full && field.kind <= conjugationKind && field.kind != typeKind && len(label) > 0 {
+		r, size := utf8.DecodeRuneInString(label)
+		label = string(unicode.ToUpper(r)) + label[size:]
+	}
+	return label
+}
+
+// format writes the fields of one morpheme, clustering person, gender and
+// number the way the style does
+func (f *Formatter) format(fields []field) string {
+	var tokens []string
+	var person, gender, number string
+	for _, field := range fields {
+		if len(field.value) == 0 {
+			continue
+		}
+		label := f.label(field)
+		switch field.kind {
+		case personKind:
+			person = label
+			continue
+		case genderKind:
+			gender = label
+			continue
+		case numberKind:
+			number = label
+			continue
+		case stateKind, degreeKind:
+			tokens = append(tokens, f.cluster(person, gender, number)...)
+			person, gender, number = "", "", ""
+		}
+		if len(label) > 0 {
+			tokens = append(tokens, label)
+		}
+	}
+	tokens = append(tokens, f.cluster(person, gender, number)...)
+	if f.style == Leipzig {
+		return strings.Join(tokens, ".")
+	}
+	return strings.Join(tokens, " ")
+}
+
+func (f *Formatter) cluster(person string, gender string, number string) []string {
+	var tokens []string
+	switch f.style {
+	case Compact:
+		tokens = []string{person + gender + number}
+	case Leipzig:
+		tokens = []string{person + number, gender}
+	default:
+		tokens = []string{person, gender, number}
+	}
+	var nonEmpty []string
+	for _, token := range tokens {
+		if len(token) > 0 {
+			nonEmpty = append(nonEmpty, token)
+		}
+	}
+	return nonEmpty
+}
+
+func (f *Formatter) part(part string, implied bool) string {
+	//+ a stem or tense already says it's a verb
+	if implied && f.style != Full {
+		return ""
+	}
+	return part
+}
+
+// Wlc formats the morphology of each morpheme of a WLC word
+func (f *Formatter) Wlc(morphology []models.WlcMorphology) string {
+	var morphemes []string
+	for _, m := range morphology {
+		kindOf := m.Type
+		if m.Type == m.Part {
+			kindOf = ""
+		}
+		morphemes = append(morphemes, f.format([]field{
+			{partKind, f.part(m.Part, len(m.Stem) > 0)},
+			{typeKind, kindOf},
+			{stemKind, m.Stem},
+			{conjugationKind, m.Conjugation},
+			{personKind, m.Person},
+			{genderKind, m.Gender},
+			{numberKind, m.Number},
+			{stateKind, m.State},
+		}))
+	}
+	separator := " + "
+	if f.style == Leipzig {
+		separator = "-"
+	}
+	return strings.Join(morphemes, separator)
+}
+
+// Gnt formats the morphology of a MorphGNT word
+func (f *Formatter) Gnt(m models.GntMorphology) string {
+	return f.format([]field{
+		{partKind, f.part(m.Part, len(m.Tense) > 0)},
+		{tenseKind, m.Tense},
+		{voiceKind, m.Voice},
+		{moodKind, m.Mood},
+		{caseKind, m.Case},
+		{personKind, m.Person},
+		{genderKind, m.Gender},
+		{numberKind, m.Number},
+		{degreeKind, m.Degree},
+	})
+}
@@ -0,0 +1,176 @@
+package describe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+var (
+	qalPerfect   = []models.WlcMorphology{{Language: "Hebrew", Part: "verb", Stem: "qal", Conjugation: "perfect (qatal)", Person: "third", Gender: "masculine", Number: "singular"}}
+	wayyiqtol    = []models.WlcMorphology{{Part: "conjunction"}, {Part: "verb", Stem: "qal", Conjugation: "sequential imperfect (wayyiqtol)", Person: "third", Gender: "masculine", Number: "singular"}}
+	commonNoun   = []models.WlcMorphology{{Part: "noun", Type: "common", Gender: "masculine", Number: "plural", State: "absolute"}}
+	suffixed     = []models.WlcMorphology{{Part: "preposition"}, {Part: "suffix", Type: "pronominal", Person: "second", Gender: "masculine", Number: "plural"}}
+	aorist       = models.GntMorphology{Part: "verb", Person: "third", Tense: "aorist", Voice: "active", Mood: "indicative", Number: "singular"}
+	participle   = models.GntMorphology{Part: "verb", Tense: "present", Voice: "active", Mood: "participle", Case: "nominative", Number: "singular", Gender: "masculine"}
+	comparative  = models.GntMorphology{Part: "adjective", Case: "accusative", Number: "singular", Gender: "masculine", Degree: "comparative"}
+	feminineNoun = models.GntMorphology{Part: "noun", Case: "nominative", Number: "singular", Gender: "feminine"}
+)
+
+func TestWlc(t *testing.T) {
+	tests := []struct {
+		style      string
+		morphology []models.WlcMorphology
+		parsing    string
+	}{
+		{Full, qalPerfect, "Verb Qal Perfect 3rd masculine singular"},
+		{Full, wayyiqtol, "Conjunction + Verb Qal Wayyiqtol 3rd masculine singular"},
+		{Full, commonNoun, "Noun common masculine plural absolute"},
+		{Full, suffixed, "Preposition + Suffix pronominal 2nd masculine plural"},
+		{Compact, qalPerfect, "Qal Pf 3ms"},
+		{Compact, wayyiqtol, "Conj + Qal wImpf 3ms"},
+		{Compact, commonNoun, "N mp abs"},
+		{Compact, suffixed, "Prep + Sfx 2mp"},
+		{Leipzig, qalPerfect, "QAL.PFV.3SG.M"},
+		{Leipzig, wayyiqtol, "CONJ-QAL.SEQ.IPFV.3SG.M"},
+		{Leipzig, commonNoun, "PL.M.ABS"},
+		{Leipzig, suffixed, "PREP-2PL.M"},
+	}
+	for _, test := range tests {
+		t.Run(test.style+" "+test.parsing, func(t *testing.T) {
+			formatter, err := New(test.style, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatter.Wlc(test.morphology); got != test.parsing {
+				t.Errorf("got %q, want %q", got, test.parsing)
+			}
+		})
+	}
+}
+
+func TestGnt(t *testing.T) {
+	tests := []struct {
+		style      string
+		morphology models.GntMorphology
+		parsing    string
+	}{
+		{Full, aorist, "Verb Aorist Active Indicative 3rd singular"},
+		{Full, participle, "Verb Present Active Participle nominative masculine singular"},
+		{Full, comparative, "Adjective accusative masculine singular comparative"},
+		{Full, feminineNoun, "Noun nominative feminine singular"},
+		{Compact, aorist, "Aor Act Ind 3s"},
+		{Compact, participle, "Pres Act Ptc Nom ms"},
+		{Compact, comparative, "Adj Acc ms Comp"},
+		{Compact, feminineNoun, "N Nom fs"},
+		{Leipzig, aorist, "AOR.ACT.IND.3SG"},
+		{Leipzig, participle, "PRS.ACT.PTCP.NOM.SG.M"},
+		{Leipzig, comparative, "ACC.SG.M.CMPR"},
+		{Leipzig, feminineNoun, "NOM.SG.F"},
+	}
+	for _, test := range tests {
+		t.Run(test.style+" "+test.parsing, func(t *testing.T) {
+			formatter, err := New(test.style, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatter.Gnt(test.morphology); got != test.parsing {
+				t.Errorf("got %q, want %q", got, test.parsing)
+			}
+		})
+	}
+}
+
+func TestLocale(t *testing.T) {
+	locale := Locale{Full: {"verb": "Verb", "perfect (qatal)": "Perfekt", "masculine": "maskulin"}}
+	formatter, err := New(Full, locale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//+ labels the locale leaves out stay in English
+	if got, want := formatter.Wlc(qalPerfect), "Verb Qal Perfekt 3rd maskulin singular"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	_, err = New(Compact, locale)
+	if err == nil || !strings.Contains(err.Error(), "the locale doesn't translate the compact style, only full") {
+		t.Errorf("got %v; want the compact style rejected", err)
+	}
+	_, err = New("gloss", nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown parsing style "gloss"`) {
+		t.Errorf("got %v; want an unknown style", err)
+	}
+}
+
+// TestShippedLocales loads each file in ./locales, which translate only
+// the full style
+func TestShippedLocales(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "locales", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no locales under locales")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			locale, err := LoadLocale(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatter, err := New(Full, locale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatter.Wlc(qalPerfect); got == "Verb Qal Perfect 3rd masculine singular" {
+				t.Errorf("nothing translated: %q", got)
+			}
+			for _, style := range []string{Compact, Leipzig} {
+				if _, err := New(style, locale); err == nil {
+					t.Errorf("%s: want an error for a style the locale doesn't translate", style)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadLocale(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"full", `{"full": {"verb": "Verbum"}}`, ""},
+		{"every style", `{"full": {"verb": "Verbum"}, "compact": {"verb": "Vb"}, "leipzig": {"verb": "V"}}`, ""},
+		{"unknown style", `{"gloss": {"verb": "Verbum"}}`, `unknown style "gloss"`},
+		{"not JSON", `{"full": `, "unexpected end of JSON input"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "locale.json")
+			err := os.WriteFile(filename, []byte(test.content), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			locale, err := LoadLocale(filename)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, %v; want error containing %q", locale, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if locale[Full]["verb"] != "Verbum" {
+				t.Errorf("got %v", locale)
+			}
+		})
+	}
+	_, err := LoadLocale(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Error("want an error for a missing file")
+	}
+}
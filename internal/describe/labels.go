@@ -0,0 +1,190 @@
+package describe
+
+// labels gives the English label of each decoded value for each style.
+// Values without a label are shown as decoded, less any parenthetical.
+var labels = map[string]map[string]string{
+	Full: {
+		"perfect (qatal)":                  "Perfect",
+		"sequential perfect (weqatal)":     "Weqatal",
+		"imperfect (yiqtol)":               "Imperfect",
+		"sequential imperfect (wayyiqtol)": "Wayyiqtol",
+		"first":                            "1st",
+		"second":                           "2nd",
+		"third":                            "3rd",
+		"common (verb)":                    "common",
+		"both (noun)":                      "common",
+	},
+	Compact: {
+		"adjective":                        "Adj",
+		"conjunction":                      "Conj",
+		"adverb":                           "Adv",
+		"noun":                             "N",
+		"pronoun":                          "Pron",
+		"preposition":                      "Prep",
+		"suffix":                           "Sfx",
+		"particle":                         "Ptcl",
+		"verb":                             "V",
+		"interjection":                     "Intj",
+		"definite article":                 "Art",
+		"demonstrative pronoun":            "Dem Pron",
+		"interrogative/indefinite pronoun": "Intr/Indf Pron",
+		"personal pronoun":                 "Pers Pron",
+		"relative pronoun":                 "Rel Pron",
+		"cardinal number":                  "card",
+		"ordinal number":                   "ord",
+		"gentilic":                         "gent",
+		"common":                           "",
+		"proper name":                      "PN",
+		"demonstrative":                    "dem",
+		"indefinite":                       "indf",
+		"interrogative":                    "intr",
+		"personal":                         "pers",
+		"relative":                         "rel",
+		"directional he":                   "dir he",
+		"paragogic he":                     "par he",
+		"paragogic nun":                    "par nun",
+		"pronominal":                       "",
+		"affirmation":                      "aff",
+		"exhortation":                      "exh",
+		"negative":                         "neg",
+		"direct object marker":             "DOM",
+		"qal":                              "Qal",
+		"niphal":                           "Niph",
+		"piel":                             "Piel",
+		"pual":                             "Pual",
+		"hiphil":                           "Hiph",
+		"hophal":                           "Hoph",
+		"hithpael":                         "Hith",
+		"qal passive":                      "Qal pass",
+		"peal":                             "Peal",
+		"peil":                             "Peil",
+		"pael":                             "Pael",
+		"aphel":                            "Aph",
+		"haphel":                           "Haph",
+		"perfect (qatal)":                  "Pf",
+		"sequential perfect (weqatal)":     "wPf",
+		"imperfect (yiqtol)":               "Impf",
+		"sequential imperfect (wayyiqtol)": "wImpf",
+		"cohortative":                      "Coh",
+		"jussive":                          "Juss",
+		"imperative":                       "Impv",
+		"participle active":                "Ptc",
+		"participle passive":               "Ptc pass",
+		"infinitive absolute":              "Inf abs",
+		"infinitive construct":             "Inf cs",
+		"first":                            "1",
+		"second":                           "2",
+		"third":                            "3",
+		"masculine":                        "m",
+		"feminine":                         "f",
+		"neuter":                           "n",
+		"common (verb)":                    "c",
+		"both (noun)":                      "b",
+		"singular":                         "s",
+		"plural":                           "p",
+		"dual":                             "d",
+		"absolute":                         "abs",
+		"construct":                        "cstr",
+		"determined":                       "det",
+		"present":                          "Pres",
+		"imperfect":                        "Impf",
+		"future":                           "Fut",
+		"aorist":                           "Aor",
+		"perfect":                          "Pf",
+		"pluperfect":                       "Plpf",
+		"active":                           "Act",
+		"middle":                           "Mid",
+		"passive":                          "Pass",
+		"indicative":                       "Ind",
+		"subjunctive":                      "Subj",
+		"optative":                         "Opt",
+		"infinitive":                       "Inf",
+		"participle":                       "Ptc",
+		"nominative":                       "Nom",
+		"genitive":                         "Gen",
+		"dative":                           "Dat",
+		"accusative":                       "Acc",
+		"comparative":                      "Comp",
+		"superlative":                      "Superl",
+	},
+	Leipzig: {
+		"adjective":                        "",
+		"conjunction":                      "CONJ",
+		"adverb":                           "ADV",
+		"noun":                             "",
+		"pronoun":                          "PRO",
+		"preposition":                      "PREP",
+		"suffix":                           "",
+		"particle":                         "",
+		"verb":                             "",
+		"interjection":                     "INTJ",
+		"definite article":                 "DEF",
+		"demonstrative pronoun":            "DEM",
+		"interrogative/indefinite pronoun": "Q/INDF",
+		"personal pronoun":                 "PRO",
+		"relative pronoun":                 "REL",
+		"cardinal number":                  "CARD",
+		"ordinal number":                   "ORD",
+		"gentilic":                         "GENT",
+		"common":                           "",
+		"proper name":                      "PN",
+		"demonstrative":                    "DEM",
+		"indefinite":                       "INDF",
+		"interrogative":                    "Q",
+		"personal":                         "",
+		"relative":                         "REL",
+		"directional he":                   "DIR",
+		"paragogic he":                     "PARAG",
+		"paragogic nun":                    "PARAG",
+		"pronominal":                       "",
+		"affirmation":                      "AFF",
+		"exhortation":                      "HORT",
+		"negative":                         "NEG",
+		"direct object marker":             "OBJ",
+		"perfect (qatal)":                  "PFV",
+		"sequential perfect (weqatal)":     "SEQ.PFV",
+		"imperfect (yiqtol)":               "IPFV",
+		"sequential imperfect (wayyiqtol)": "SEQ.IPFV",
+		"cohortative":                      "COH",
+		"jussive":                          "JUSS",
+		"imperative":                       "IMP",
+		"participle active":                "PTCP.ACT",
+		"participle passive":               "PTCP.PASS",
+		"infinitive absolute":              "INF.ABS",
+		"infinitive construct":             "INF.CSTR",
+		"first":                            "1",
+		"second":                           "2",
+		"third":                            "3",
+		"masculine":                        "M",
+		"feminine":                         "F",
+		"neuter":                           "N",
+		"common (verb)":                    "C",
+		"both (noun)":                      "C",
+		"singular":                         "SG",
+		"plural":                           "PL",
+		"dual":                             "DU",
+		"absolute":                         "ABS",
+		"construct":                        "CSTR",
+		"determined":                       "DET",
+		"present":                          "PRS",
+		"imperfect":                        "IPFV",
+		"future":                           "FUT",
+		"aorist":                           "AOR",
+		"perfect":                          "PRF",
+		"pluperfect":                       "PLPRF",
+		"active":                           "ACT",
+		"middle":                           "MID",
+		"passive":                          "PASS",
+		"indicative":                       "IND",
+		"subjunctive":                      "SBJV",
+		"optative":                         "OPT",
+		"infinitive":                       "INF",
+		"participle":                       "PTCP",
+		"nominative":                       "NOM",
+		"genitive":                         "GEN",
+		"dative":                           "DAT",
+		"accusative":                       "ACC",
+		"comparative":                      "CMPR",
+		"superlative":                      "SUPL",
+	},
+}
@@ -0,0 +1,203 @@
+// Package greek derives search and alternate-script forms of polytonic
+// Greek: a folded form without accents or breathings, Beta Code and an
+// SBL-style transliteration.
+package greek
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// combining marks as they appear after NFD decomposition
+const (
+	smooth      = '\u0313'
+	rough       = '\u0314'
+	acute       = '\u0301'
+	grave       = '\u0300'
+	circumflex  = '\u0342'
+	diaeresis   = '\u0308'
+	iotaSubscr  = '\u0345'
+	macron      = '\u0304'
+	ogonek      = '\u0328'
+	finalSigma  = '\u03C2'
+	medialSigma = '\u03C3'
+)
+
+// letter is a base letter with the combining marks that follow it
+type letter struct {
+	base  rune
+	marks []rune
+}
+
+func (l letter) has(mark rune) bool {
+	for _, m := range l.marks {
+		if m == mark {
+			return true
+		}
+	}
+	return false
+}
+
+func (l letter) lower() rune {
+	return unicode.ToLower(l.base)
+}
+
+func split(text string) []letter {
+	var letters []letter
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) && len(letters) > 0 {
+			letters[len(letters)-1].marks = append(letters[len(letters)-1].marks, r)
+			continue
+		}
+		letters = append(letters, letter{base: r})
+	}
+	return letters
+}
+
+// Fold lowercases text and strips accents, breathings, diaeresis and iota
+// subscript, writing every sigma as σ, so Ἰησοῦς becomes ιησουσ
+func Fold(text string) string {
+	var b strings.Builder
+	for _, l := range split(text) {
+		r := l.lower()
+		if r == finalSigma {
+			r = medialSigma
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var betaLetters = map[rune]string{
+	'α': "a", 'β': "b", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "h",
+	'θ': "q", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "c",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "u",
+	'φ': "f", 'χ': "x", 'ψ': "y", 'ω': "w",
+	'\u0387': ":", '\u00B7': ":", '\u037E': ";", ';': ";", '\u2019': "'",
+}
+
+// betaMarks lists the diacritics in the order Beta Code writes them
+var betaMarks = []struct {
+	mark rune
+	code string
+}{
+	{smooth, ")"},
+	{rough, "("},
+	{diaeresis, "+"},
+	{acute, "/"},
+	{grave, "\\"},
+	{circumflex, "="},
+	{iotaSubscr, "|"},
+}
+
+// BetaCode writes text in lowercase TLG Beta Code, so Ἰησοῦς becomes
+// *)ihsou=s. Capitals are marked with * and carry their diacritics
+// before the letter.
+func BetaCode(text string) string {
+	var b strings.Builder
+	for _, l := range split(text) {
+		code, ok := betaLetters[l.lower()]
+		if !ok {
+			b.WriteRune(l.base)
+			continue
+		}
+		var marks string
+		for _, m := range betaMarks {
+			if l.has(m.mark) {
+				marks += m.code
+			}
+		}
+		if unicode.IsUpper(l.base) {
+			b.WriteString("*" + marks + code)
+			continue
+		}
+		b.WriteString(code + marks)
+	}
+	return b.String()
+}
+
+var sblLetters = map[rune]string{
+	'α': "a", 'β': "b", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "ē",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "ph", 'χ': "ch", 'ψ': "ps", 'ω': "ō",
+}
+
+// subscripts gives the SBL form of a vowel with iota subscript
+var subscripts = map[rune]string{
+	'α': "a" + string(ogonek),
+	'η': "e" + string(ogonek) + string(macron),
+	'ω': "o" + string(ogonek) + string(macron),
+}
+
+// diphthong reports whether first and second are read as one vowel, in
+// which case a rough breathing on second is written before first
+func diphthong(first letter, second letter) bool {
+	if second.has(diaeresis) {
+		return false
+	}
+	switch second.lower() {
+	case 'ι':
+		return strings.ContainsRune("αεου", first.lower())
+	case 'υ':
+		return strings.ContainsRune("αεηοω", first.lower())
+	}
+	return false
+}
+
+// Transliterate writes text in the SBL academic style, so Ἰησοῦς becomes
+// Iēsous, υἱός huios and ἄγγελος angelos
+func Transliterate(text string) string {
+	letters := split(text)
+	tokens := make([]string, len(letters))
+	for i, l := range letters {
+		r := l.lower()
+		token, ok := sblLetters[r]
+		if !ok {
+			tokens[i] = string(l.base)
+			continue
+		}
+		var previous, next letter
+		if i > 0 {
+			previous = letters[i-1]
+		}
+		if i+1 < len(letters) {
+			next = letters[i+1]
+		}
+		switch {
+		case l.has(iotaSubscr) && len(subscripts[r]) > 0:
+			token = subscripts[r]
+		case r == 'γ' && strings.ContainsRune("γκξχ", next.lower()):
+			token = "n"
+		case r == 'υ' && (diphthong(previous, l) || (next.lower() == 'ι' && !next.has(diaeresis))):
+			token = "u"
+		case r == 'ρ' && (l.has(rough) || !unicode.IsLetter(previous.base) || previous.lower() == 'ρ'):
+			token = "rh"
+		}
+		tokens[i] += token
+		if l.has(rough) && r != 'ρ' {
+			if i > 0 && diphthong(previous, l) {
+				tokens[i-1] = "h" + tokens[i-1]
+			} else {
+				tokens[i] = "h" + tokens[i]
+			}
+		}
+	}
+	var b strings.Builder
+	for i, token := range tokens {
+		if unicode.IsUpper(letters[i].base) {
+			token = capitalize(token)
+		}
+		b.WriteString(token)
+	}
+	return norm.NFC.String(b.String())
+}
+
+func capitalize(text string) string {
+	for i, r := range text {
+		return string(unicode.ToUpper(r)) + text[i+len(string(r)):]
+	}
+	return text
+}
@@ -0,0 +1,72 @@
+package greek
+
+import "testing"
+
+func TestFoldAndBetaCode(t *testing.T) {
+	tests := []struct {
+		text     string
+		folded   string
+		betaCode string
+	}{
+		{"Ἰησοῦς", "ιησουσ", "*)ihsou=s"},
+		{"υἱός", "υιοσ", "ui(o/s"},
+		{"ἄγγελος", "αγγελοσ", "a)/ggelos"},
+		{"Βίβλος", "βιβλοσ", "*bi/blos"},
+		{"Δαυὶδ", "δαυιδ", "*daui\\d"},
+		{"Ἀβραάμ", "αβρααμ", "*)abraa/m"},
+		{"ῥῆμα", "ρημα", "r(h=ma"},
+		{"Ῥώμη", "ρωμη", "*(rw/mh"},
+		{"ᾠδή", "ωδη", "w)|dh/"},
+		{"τῷ", "τω", "tw=|"},
+		{"πρωΐ", "πρωι", "prwi+/"},
+		{"Μωϋσῆς", "μωυσησ", "*mwu+sh=s"},
+		{"Χριστός", "χριστοσ", "*xristo/s"},
+		{"ψυχή", "ψυχη", "yuxh/"},
+		{"δι’", "δι’", "di'"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Fold(test.text); got != test.folded {
+				t.Errorf("folded: got %q, want %q", got, test.folded)
+			}
+			if got := BetaCode(test.text); got != test.betaCode {
+				t.Errorf("Beta Code: got %q, want %q", got, test.betaCode)
+			}
+		})
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		text            string
+		transliteration string
+	}{
+		{"Ἰησοῦς", "Iēsous"},
+		{"υἱός", "huios"},
+		{"ἄγγελος", "angelos"},
+		{"ἄγκυρα", "ankyra"},
+		{"ἐλέγχω", "elenchō"},
+		{"γενέσεως", "geneseōs"},
+		{"Χριστός", "Christos"},
+		{"ψυχή", "psychē"},
+		{"ζωή", "zōē"},
+		{"αὐτοῦ", "autou"},
+		{"εὐαγγέλιον", "euangelion"},
+		{"οὐρανοῦ", "ouranou"},
+		{"ῥῆμα", "rhēma"},
+		{"Ῥώμη", "Rhōmē"},
+		{"Ἅιδης", "Haidēs"},
+		{"ᾠδή", "ǭdē"},
+		{"σοφίᾳ", "sophią"},
+		{"δι’", "di’"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Transliterate(test.text); got != test.transliteration {
+				t.Errorf("got %q, want %q", got, test.transliteration)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package greek
+
+import (
+	"strings"
+	"unicode"
+)
+
+// elision marks end a word rather than punctuate it, as in δι’
+const elision = "\u2019\u02BC'"
+
+// sentence and clause ends: period and question mark, then comma and
+// raised dot
+const (
+	sentenceEnds = ".;\u037E"
+	clauseEnds   = ",\u0387\u00B7"
+)
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) || strings.ContainsRune(elision, r)
+}
+
+// SplitPunctuation separates the punctuation before and after a word, so
+// (ὃ gives "(", "ὃ", "" and Ἀβραάμ. gives "", "Ἀβραάμ", "."
+func SplitPunctuation(text string) (leading string, word string, trailing string) {
+	start := strings.IndexFunc(text, isWordRune)
+	if start < 0 {
+		return text, "", ""
+	}
+	end := strings.LastIndexFunc(text, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.Is(unicode.Mn, r)
+	})
+	//+ a token of elision marks alone has no letter to end at
+	if end < start {
+		end = start
+	}
+	//+ keep an elision mark that follows the last letter
+	rest := text[end:]
+	for i, r := range rest {
+		if i > 0 && !isWordRune(r) {
+			return text[:start], text[start : end+i], rest[i:]
+		}
+	}
+	return text[:start], text[start:], ""
+}
+
+// EndsSentence reports whether trailing punctuation closes a sentence
+func EndsSentence(trailing string) bool {
+	return strings.ContainsAny(trailing, sentenceEnds)
+}
+
+// EndsClause reports whether trailing punctuation closes a clause. Every
+// sentence end is also a clause end.
+func EndsClause(trailing string) bool {
+	return strings.ContainsAny(trailing, clauseEnds) || EndsSentence(trailing)
+}
@@ -0,0 +1,58 @@
+package greek
+
+import "testing"
+
+func TestSplitPunctuation(t *testing.T) {
+	tests := []struct {
+		text     string
+		leading  string
+		word     string
+		trailing string
+	}{
+		{"λόγος", "", "λόγος", ""},
+		{"(ὃ", "(", "ὃ", ""},
+		{"Ἀβραάμ.", "", "Ἀβραάμ", "."},
+		{"αὐτοῦ,", "", "αὐτοῦ", ","},
+		{"(ἐστιν)\u0387", "(", "ἐστιν", ")\u0387"},
+		{"δι’", "", "δι’", ""},
+		{"ἀλλ’,", "", "ἀλλ’", ","},
+		{"’", "", "’", ""},
+		{"’’", "", "’’", ""},
+		{"(’)", "(", "’", ")"},
+		{"—", "—", "", ""},
+		{"", "", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			leading, word, trailing := SplitPunctuation(test.text)
+			if leading != test.leading || word != test.word || trailing != test.trailing {
+				t.Errorf("got %q, %q, %q; want %q, %q, %q", leading, word, trailing, test.leading, test.word, test.trailing)
+			}
+		})
+	}
+}
+
+func TestEndsSentenceAndClause(t *testing.T) {
+	tests := []struct {
+		trailing string
+		sentence bool
+		clause   bool
+	}{
+		{"", false, false},
+		{".", true, true},
+		{";", true, true},
+		{"\u037E", true, true},
+		{",", false, true},
+		{"\u0387", false, true},
+		{"\u00B7", false, true},
+		{")", false, false},
+	}
+	for _, test := range tests {
+		if got := EndsSentence(test.trailing); got != test.sentence {
+			t.Errorf("EndsSentence(%q) = %v, want %v", test.trailing, got, test.sentence)
+		}
+		if got := EndsClause(test.trailing); got != test.clause {
+			t.Errorf("EndsClause(%q) = %v, want %v", test.trailing, got, test.clause)
+		}
+	}
+}
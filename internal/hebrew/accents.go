@@ -0,0 +1,109 @@
+package hebrew
+
+import "fmt"
+
+// Levels of the Masoretic division of a verse. Each disjunctive accent
+// closes the segment at its level and every segment nested inside it.
+const (
+	// VerseEnd is the level of silluq, which ends the verse
+	VerseEnd = iota
+	// Half is the level of atnach and, in the poetic books, ole weyored
+	Half
+	// Phrase is the level of zaqef, segolta and tipcha
+	Phrase
+	// Clause is the level of revia, pashta, tevir and their peers
+	Clause
+	// Minor is the level of geresh, pazer, telisha gedola and their peers
+	Minor
+	// Conjunctive is the depth of a word whose accent joins it to the next
+	Conjunctive
+)
+
+type disjunctive struct {
+	name  string
+	level int
+}
+
+var proseAccents = map[rune]disjunctive{
+	'\u0591': {"atnach", Half},
+	'\u0592': {"segolta", Phrase},
+	'\u0593': {"shalshelet", Phrase},
+	'\u0594': {"zaqef qatan", Phrase},
+	'\u0595': {"zaqef gadol", Phrase},
+	'\u0596': {"tipcha", Phrase},
+	'\u0597': {"revia", Clause},
+	'\u0598': {"zarqa", Clause},
+	'\u05AE': {"zarqa", Clause},
+	'\u0599': {"pashta", Clause},
+	'\u059A': {"yetiv", Clause},
+	'\u059B': {"tevir", Clause},
+	'\u059C': {"geresh", Minor},
+	'\u059D': {"geresh", Minor},
+	'\u059E': {"gershayim", Minor},
+	'\u059F': {"qarney para", Minor},
+	'\u05A0': {"telisha gedola", Minor},
+	'\u05A1': {"pazer", Minor},
+}
+
+// poeticAccents is the system of Psalms, Proverbs and the poetry of Job,
+// in which tipcha is the conjunctive tarcha and zarqa the conjunctive
+// tsinnorit
+var poeticAccents = map[rune]disjunctive{
+	'\u05AB': {"ole weyored", Half},
+	'\u0591': {"atnach", Half},
+	'\u0597': {"revia", Phrase},
+	'\u05AE': {"tsinnor", Phrase},
+	'\u05AD': {"dehi", Phrase},
+	'\u05A1': {"pazer", Clause},
+	'\u0593': {"shalshelet", Clause},
+}
+
+// Division places a word in the Masoretic division of its verse
+type Division struct {
+	// Accent names the word's strongest disjunctive accent, if it has one
+	Accent string
+	// Depth is the level of that accent, or Conjunctive
+	Depth int
+	// Half counts the segments at the Half level, so it's 1 before atnach
+	// and 2 after it, or 1 to 3 in a poetic verse with ole weyored
+	Half int
+	// Segment numbers the word's segment at each level below the verse,
+	// e.g. 2.1.3.1
+	Segment string
+}
+
+// Divide reads the accents of the words of one verse and places each word
+// in the verse's division. The last word carries silluq.
+func Divide(words []string, poetic bool) []Division {
+	accents := proseAccents
+	if poetic {
+		accents = poeticAccents
+	}
+	divisions := make([]Division, len(words))
+	counters := [Conjunctive]int{0, 1, 1, 1, 1}
+	for i, word := range words {
+		strongest := disjunctive{level: Conjunctive}
+		for _, r := range word {
+			if accent, ok := accents[r]; ok && accent.level < strongest.level {
+				strongest = accent
+			}
+		}
+		if i == len(words)-1 {
+			strongest = disjunctive{"silluq", VerseEnd}
+		}
+		divisions[i] = Division{
+			Accent:  strongest.name,
+			Depth:   strongest.level,
+			Half:    counters[Half],
+			Segment: fmt.Sprintf("%d.%d.%d.%d", counters[Half], counters[Phrase], counters[Clause], counters[Minor]),
+		}
+		if strongest.level == VerseEnd || strongest.level == Conjunctive {
+			continue
+		}
+		counters[strongest.level]++
+		for level := strongest.level + 1; level < Conjunctive; level++ {
+			counters[level] = 1
+		}
+	}
+	return divisions
+}
@@ -0,0 +1,70 @@
+package hebrew
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDivide(t *testing.T) {
+	tests := []struct {
+		name      string
+		words     []string
+		poetic    bool
+		divisions string
+	}{
+		{
+			"Gen 1:1",
+			[]string{"בְּ/רֵאשִׁ֖ית", "בָּרָ֣א", "אֱלֹהִ֑ים", "אֵ֥ת", "הַ/שָּׁמַ֖יִם", "וְ/אֵ֥ת", "הָ/אָֽרֶץ"},
+			false,
+			"tipcha 2 1 1.1.1.1; - 5 1 1.2.1.1; atnach 1 1 1.2.1.1; - 5 2 2.1.1.1; tipcha 2 2 2.1.1.1; - 5 2 2.2.1.1; silluq 0 2 2.2.1.1",
+		},
+		{
+			"lower levels restart",
+			[]string{"a\u059C", "b\u0599", "c\u059C", "d"},
+			false,
+			"geresh 4 1 1.1.1.1; pashta 3 1 1.1.1.2; geresh 4 1 1.1.2.1; silluq 0 1 1.1.2.2",
+		},
+		{
+			"strongest accent wins",
+			[]string{"a\u0597\u0596", "b"},
+			false,
+			"tipcha 2 1 1.1.1.1; silluq 0 1 1.2.1.1",
+		},
+		{
+			"prose ignores ole weyored",
+			[]string{"a\u05AB", "b", "c\u0591", "d\u0596", "e"},
+			false,
+			"- 5 1 1.1.1.1; - 5 1 1.1.1.1; atnach 1 1 1.1.1.1; tipcha 2 2 2.1.1.1; silluq 0 2 2.2.1.1",
+		},
+		{
+			"poetic",
+			[]string{"a\u05AB", "b", "c\u0591", "d\u0596", "e"},
+			true,
+			"ole weyored 1 1 1.1.1.1; - 5 2 2.1.1.1; atnach 1 2 2.1.1.1; - 5 3 3.1.1.1; silluq 0 3 3.1.1.1",
+		},
+		{
+			"poetic revia",
+			[]string{"a\u0597", "b\u05A1", "c"},
+			true,
+			"revia 2 1 1.1.1.1; pazer 3 1 1.2.1.1; silluq 0 1 1.2.2.1",
+		},
+		{"one word", []string{"a\u0591"}, false, "silluq 0 1 1.1.1.1"},
+		{"empty", nil, false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, division := range Divide(test.words, test.poetic) {
+				accent := division.Accent
+				if len(accent) == 0 {
+					accent = "-"
+				}
+				got = append(got, fmt.Sprintf("%s %d %d %s", accent, division.Depth, division.Half, division.Segment))
+			}
+			if strings.Join(got, "; ") != test.divisions {
+				t.Errorf("got %s, want %s", strings.Join(got, "; "), test.divisions)
+			}
+		})
+	}
+}
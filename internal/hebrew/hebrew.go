@@ -0,0 +1,68 @@
+// Package hebrew derives search forms of pointed Hebrew text: consonants
+// only, vowels without cantillation, and plain NFC.
+package hebrew
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// morphemeSeparator splits the morphemes of a WLC word
+const morphemeSeparator = "/"
+
+// IsLetter reports whether r is a Hebrew consonant, including final forms
+func IsLetter(r rune) bool {
+	return r >= '\u05D0' && r <= '\u05EA'
+}
+
+// IsAccent reports whether r is a cantillation mark or one of the marks
+// read with them: meteg, paseq, sof pasuq and the upper and lower dots
+func IsAccent(r rune) bool {
+	switch {
+	case r >= '\u0591' && r <= '\u05AF':
+		return true
+	case r == '\u05BD', r == '\u05C0', r == '\u05C3', r == '\u05C4', r == '\u05C5', r == '\u05C6':
+		return true
+	}
+	return false
+}
+
+// IsPoint reports whether r is a vowel point, dagesh, rafe or shin/sin dot
+func IsPoint(r rune) bool {
+	switch {
+	case r >= '\u05B0' && r <= '\u05BC':
+		return true
+	case r == '\u05BF', r == '\u05C1', r == '\u05C2', r == '\u05C7':
+		return true
+	}
+	return false
+}
+
+// Normalized is text in NFC with the morpheme separators removed
+func Normalized(text string) string {
+	return norm.NFC.String(strings.Replace(text, morphemeSeparator, "", -1))
+}
+
+// Consonantal keeps only the consonants of text, so שָׁלֹ֖ום becomes שלום
+func Consonantal(text string) string {
+	return keep(text, IsLetter)
+}
+
+// Pointed keeps the consonants and vowel points of text and drops the
+// cantillation, so בְּ/רֵאשִׁ֖ית becomes בְּרֵאשִׁית
+func Pointed(text string) string {
+	return keep(text, func(r rune) bool {
+		return IsLetter(r) || IsPoint(r)
+	})
+}
+
+func keep(text string, fn func(r rune) bool) string {
+	var b strings.Builder
+	for _, r := range text {
+		if fn(r) {
+			b.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(b.String())
+}
@@ -0,0 +1,79 @@
+package hebrew
+
+import "testing"
+
+func TestForms(t *testing.T) {
+	tests := []struct {
+		text        string
+		consonantal string
+		pointed     string
+		normalized  string
+	}{
+		{"בְּ/רֵאשִׁ֖ית", "בראשית", "בְּרֵאשִׁית", "בְּרֵאשִׁ֖ית"},
+		{"בָּרָ֣א", "ברא", "בָּרָא", "בָּרָ֣א"},
+		{"אֱלֹהִ֑ים", "אלהים", "אֱלֹהִים", "אֱלֹהִ֑ים"},
+		{"הַ/שָּׁמַ֖יִם", "השמים", "הַשָּׁמַיִם", "הַשָּׁמַ֖יִם"},
+		{"וְ/הָ/אָ֖רֶץ", "והארץ", "וְהָאָרֶץ", "וְהָאָ֖רֶץ"},
+		//+ meteg is read with the accents
+		{"הָ/אָֽרֶץ", "הארץ", "הָאָרֶץ", "הָאָֽרֶץ"},
+		//+ paseq and sof pasuq
+		{"פְּרִ֔י ׀", "פרי", "פְּרִי", "פְּרִ֔י ׀"},
+		{"יהוה׃", "יהוה", "יהוה", "יהוה׃"},
+		//+ shin dot written before the vowel is reordered to NFC
+		{"\u05E9\u05C1\u05B8", "\u05E9", "\u05E9\u05B8\u05C1", "\u05E9\u05B8\u05C1"},
+		{"", "", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Consonantal(test.text); got != test.consonantal {
+				t.Errorf("consonantal: got %q, want %q", got, test.consonantal)
+			}
+			if got := Pointed(test.text); got != test.pointed {
+				t.Errorf("pointed: got %q, want %q", got, test.pointed)
+			}
+			if got := Normalized(test.text); got != test.normalized {
+				t.Errorf("normalized: got %q, want %q", got, test.normalized)
+			}
+		})
+	}
+}
+
+func TestClasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      rune
+		letter bool
+		accent bool
+		point  bool
+	}{
+		{"alef", 'א', true, false, false},
+		{"final kaf", 'ך', true, false, false},
+		{"tav", 'ת', true, false, false},
+		{"etnahta", '\u0591', false, true, false},
+		{"masora circle", '\u05AF', false, true, false},
+		{"meteg", '\u05BD', false, true, false},
+		{"paseq", '\u05C0', false, true, false},
+		{"sof pasuq", '\u05C3', false, true, false},
+		{"upper dot", '\u05C4', false, true, false},
+		{"shewa", '\u05B0', false, false, true},
+		{"dagesh", '\u05BC', false, false, true},
+		{"rafe", '\u05BF', false, false, true},
+		{"shin dot", '\u05C1', false, false, true},
+		{"qamets qatan", '\u05C7', false, false, true},
+		{"maqaf", '\u05BE', false, false, false},
+		{"latin", 'a', false, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsLetter(test.r); got != test.letter {
+				t.Errorf("IsLetter = %v, want %v", got, test.letter)
+			}
+			if got := IsAccent(test.r); got != test.accent {
+				t.Errorf("IsAccent = %v, want %v", got, test.accent)
+			}
+			if got := IsPoint(test.r); got != test.point {
+				t.Errorf("IsPoint = %v, want %v", got, test.point)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package hebrew
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Academic is the SBL academic style: bərēʾšîṯ bārāʾ ʾĕlōhîm
+	Academic = "sbl"
+	// Simple is the SBL general-purpose style: bereshit bara elohim
+	Simple = "simple"
+)
+
+const tetragrammaton = "\u05D9\u05D4\u05D5\u05D4"
+
+// points read by the transliterator
+const (
+	shewa       = '\u05B0'
+	hatefSegol  = '\u05B1'
+	hatefPatah  = '\u05B2'
+	hatefQamets = '\u05B3'
+	hireq       = '\u05B4'
+	tsere       = '\u05B5'
+	segol       = '\u05B6'
+	patah       = '\u05B7'
+	qamets      = '\u05B8'
+	holem       = '\u05B9'
+	holemHaser  = '\u05BA'
+	qibbuts     = '\u05BB'
+	dagesh      = '\u05BC'
+	sinDot      = '\u05C2'
+	qametsQatan = '\u05C7'
+)
+
+// vowel sounds, written in the academic style
+const (
+	noVowel    = ""
+	vocalShewa = "ə"
+	shortA     = "a"
+	longA      = "ā"
+	shortE     = "e"
+	longE      = "ē"
+	shortI     = "i"
+	shortO     = "o"
+	longO      = "ō"
+	shortU     = "u"
+	reducedA   = "ă"
+	reducedE   = "ĕ"
+	reducedO   = "ŏ"
+	fullA      = "â"
+	fullE      = "ê"
+	fullI      = "î"
+	fullO      = "ô"
+	fullU      = "û"
+)
+
+var vowelPoints = map[rune]string{
+	hatefSegol:  reducedE,
+	hatefPatah:  reducedA,
+	hatefQamets: reducedO,
+	hireq:       shortI,
+	tsere:       longE,
+	segol:       shortE,
+	patah:       shortA,
+	qamets:      longA,
+	holem:       longO,
+	holemHaser:  longO,
+	qibbuts:     shortU,
+	qametsQatan: shortO,
+}
+
+var academicConsonants = map[rune]string{
+	'א': "ʾ", 'ב': "ḇ", 'ג': "ḡ", 'ד': "ḏ", 'ה': "h", 'ו': "w", 'ז': "z",
+	'ח': "ḥ", 'ט': "ṭ", 'י': "y", 'כ': "ḵ", 'ך': "ḵ", 'ל': "l", 'מ': "m",
+	'ם': "m", 'נ': "n", 'ן': "n", 'ס': "s", 'ע': "ʿ", 'פ': "p̄", 'ף': "p̄",
+	'צ': "ṣ", 'ץ': "ṣ", 'ק': "q", 'ר': "r", 'ש': "š", 'ת': "ṯ",
+}
+
+var simpleConsonants = map[rune]string{
+	'א': "", 'ב': "v", 'ג': "g", 'ד': "d", 'ה': "h", 'ו': "v", 'ז': "z",
+	'ח': "kh", 'ט': "t", 'י': "y", 'כ': "kh", 'ך': "kh", 'ל': "l", 'מ': "m",
+	'ם': "m", 'נ': "n", 'ן': "n", 'ס': "s", 'ע': "", 'פ': "f", 'ף': "f",
+	'צ': "ts", 'ץ': "ts", 'ק': "q", 'ר': "r", 'ש': "sh", 'ת': "t",
+}
+
+// stops gives the begadkephat letters with dagesh, the same in both styles
+var stops = map[rune]string{
+	'ב': "b", 'ג': "g", 'ד': "d", 'כ': "k", 'ך': "k", 'פ': "p", 'ף': "p", 'ת': "t",
+}
+
+var simpleVowels = map[string]string{
+	vocalShewa: "e", longA: "a", longE: "e", longO: "o", reducedA: "a",
+	reducedE: "e", reducedO: "o", fullA: "ah", fullE: "e", fullI: "i",
+	fullO: "o", fullU: "u",
+}
+
+// consonant is a letter with its points, in the order they were written
+type consonant struct {
+	base     rune
+	marks    []rune
+	morpheme int
+}
+
+func (c consonant) has(mark rune) bool {
+	for _, m := range c.marks {
+		if m == mark {
+			return true
+		}
+	}
+	return false
+}
+
+func (c consonant) vowel() string {
+	for _, m := range c.marks {
+		if v, ok := vowelPoints[m]; ok {
+			return v
+		}
+	}
+	return noVowel
+}
+
+func (c consonant) bare() bool {
+	return c.vowel() == noVowel && !c.has(shewa) && !c.has(dagesh)
+}
+
+// sound is what one consonant contributes: itself, doubled or not, and
+// the vowel after it. Matres lectionis contribute nothing of their own.
+type sound struct {
+	stop    bool
+	doubled bool
+	furtive bool
+	silent  bool
+	vowel   string
+}
+
+func splitConsonants(text string) []consonant {
+	var letters []consonant
+	morpheme := 0
+	for _, r := range text {
+		switch {
+		case string(r) == morphemeSeparator:
+			morpheme++
+		case IsLetter(r):
+			letters = append(letters, consonant{base: r, morpheme: morpheme})
+		case IsPoint(r) && len(letters) > 0:
+			letters[len(letters)-1].marks = append(letters[len(letters)-1].marks, r)
+		}
+	}
+	return letters
+}
+
+// isLong reports whether a vowel is long. Qamets is always read as ā
+// unless it's written as qamets qatan, since WLC doesn't mark qamets hatuf.
+func isLong(vowel string) bool {
+	switch vowel {
+	case longA, longE, longO, fullA, fullE, fullI, fullO, fullU:
+		return true
+	}
+	return false
+}
+
+// analyze works out the sound of each consonant of a word. It needs the
+// whole word, since dagesh, shewa and the vowel letters are read from
+// their neighbours.
+func analyze(letters []consonant) []sound {
+	sounds := make([]sound, len(letters))
+	last := len(letters) - 1
+	for i, c := range letters {
+		s := &sounds[i]
+		s.vowel = c.vowel()
+		var previous *sound
+		var before consonant
+		if i > 0 {
+			previous = &sounds[i-1]
+			before = letters[i-1]
+		}
+		afterVowel := previous != nil && previous.vowel != noVowel && previous.vowel != vocalShewa
+		switch {
+		//+ shureq and holem male stand for the vowel of a consonant that has none
+		case c.base == 'ו' && s.vowel == noVowel && c.has(dagesh) && (previous == nil || before.vowel() == noVowel && !before.has(shewa)):
+			s.silent = true
+			s.vowel = fullU
+			continue
+		case c.base == 'ו' && c.has(holem) && (previous == nil || before.vowel() == noVowel && !before.has(shewa)):
+			s.silent = true
+			s.vowel = fullO
+			continue
+		case c.base == 'י' && c.bare() && previous != nil && !previous.silent:
+			switch previous.vowel {
+			case shortI:
+				previous.vowel, s.silent = fullI, true
+			case longE, shortE:
+				previous.vowel, s.silent = fullE, true
+			}
+		case c.base == 'ה' && c.bare() && i == last && previous != nil:
+			switch previous.vowel {
+			case longA:
+				previous.vowel, s.silent = fullA, true
+			case longE, shortE:
+				previous.vowel, s.silent = fullE, true
+			case longO, fullO:
+				previous.vowel, s.silent = fullO, true
+			}
+		}
+		if s.silent {
+			continue
+		}
+		if c.has(dagesh) {
+			_, s.stop = stops[c.base]
+			mappiq := c.base == 'ה' && i == last
+			s.doubled = i > 0 && afterVowel && !mappiq
+		}
+		if c.has(shewa) {
+			switch {
+			case i == last:
+			case i == 0, s.doubled:
+				s.vowel = vocalShewa
+			case before.has(shewa) && (previous.vowel == noVowel):
+				s.vowel = vocalShewa
+			case isLong(previous.vowel):
+				s.vowel = vocalShewa
+			case i < last && letters[i+1].base == c.base:
+				s.vowel = vocalShewa
+			}
+		}
+		if i == last && s.vowel == shortA && i > 0 && afterVowel && (c.base == 'ח' || c.base == 'ע' || c.base == 'ה' && c.has(dagesh)) {
+			s.furtive = true
+		}
+	}
+	return sounds
+}
+
+func render(c consonant, s sound, style string) string {
+	if s.silent && s.vowel == noVowel {
+		return ""
+	}
+	if s.silent {
+		return vowel(s.vowel, style)
+	}
+	table := academicConsonants
+	if style == Simple {
+		table = simpleConsonants
+	}
+	letter := table[c.base]
+	if s.stop {
+		letter = stops[c.base]
+	}
+	if c.base == 'ש' && c.has(sinDot) {
+		letter = "ś"
+		if style == Simple {
+			letter = "s"
+		}
+	}
+	if s.doubled && (style == Academic || utf8.RuneCountInString(letter) == 1) {
+		letter += letter
+	}
+	if s.furtive {
+		return vowel(s.vowel, style) + letter
+	}
+	return letter + vowel(s.vowel, style)
+}
+
+func vowel(v string, style string) string {
+	if style == Simple {
+		if simple, ok := simpleVowels[v]; ok {
+			return simple
+		}
+	}
+	return v
+}
+
+// TransliterateMorphemes writes each morpheme of a WLC word in style. The
+// word is read as a whole, so a morpheme comes out as it sounds in the
+// word: the ב of בְּ/רֵאשִׁית is bə, and the ב of וּ/בְ/יוֹם is ḇə.
+func TransliterateMorphemes(text string, style string) []string {
+	letters := splitConsonants(text)
+	sounds := analyze(letters)
+	morphemes := make([]string, strings.Count(text, morphemeSeparator)+1)
+	consonants := make([]string, len(morphemes))
+	for i, c := range letters {
+		morphemes[c.morpheme] += render(c, sounds[i], style)
+		consonants[c.morpheme] += string(c.base)
+	}
+	//+ the divine name is pointed with the vowels of its qere, so it's
+	//+ written by its consonants as SBL does
+	for i := range morphemes {
+		if consonants[i] == tetragrammaton {
+			morphemes[i] = "YHWH"
+		}
+	}
+	return morphemes
+}
+
+// Transliterate writes a WLC word in style, Academic or Simple
+func Transliterate(text string, style string) string {
+	return strings.Join(TransliterateMorphemes(text, style), "")
+}
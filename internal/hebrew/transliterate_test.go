@@ -0,0 +1,66 @@
+package hebrew
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		text     string
+		academic string
+		simple   string
+	}{
+		{"בְּ/רֵאשִׁ֖ית", "bərēʾšîṯ", "bereshit"},
+		{"בָּרָ֣א", "bārāʾ", "bara"},
+		{"אֱלֹהִ֑ים", "ʾĕlōhîm", "elohim"},
+		{"אֵ֥ת", "ʾēṯ", "et"},
+		{"הַ/שָּׁמַ֖יִם", "haššāmayim", "hashamayim"},
+		{"וְ/אֵ֥ת", "wəʾēṯ", "veet"},
+		{"הָ/אָֽרֶץ", "hāʾāreṣ", "haarets"},
+		{"תוֹלְד֧וֹת", "ṯôləḏôṯ", "toledot"},
+		{"שָׁל֖וֹם", "šālôm", "shalom"},
+		{"יִשְׂרָאֵ֑ל", "yiśrāʾēl", "yisrael"},
+		{"דָּוִ֖ד", "dāwiḏ", "david"},
+		{"מֶ֫לֶךְ", "meleḵ", "melekh"},
+		{"רוּחַ", "rûaḥ", "ruakh"},
+		{"בַּ/יּ֥וֹם", "bayyôm", "bayyom"},
+		{"יִּקַּח", "yiqqaḥ", "yiqqakh"},
+		{"יְהוָ֥ה", "YHWH", "YHWH"},
+		//+ qamets qatan, which WLC doesn't mark but other texts do
+		{"חׇכְמָ֣ה", "ḥoḵmâ", "khokhmah"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := Transliterate(test.text, Academic); got != test.academic {
+				t.Errorf("academic: got %q, want %q", got, test.academic)
+			}
+			if got := Transliterate(test.text, Simple); got != test.simple {
+				t.Errorf("simple: got %q, want %q", got, test.simple)
+			}
+		})
+	}
+}
+
+func TestTransliterateMorphemes(t *testing.T) {
+	tests := []struct {
+		text      string
+		style     string
+		morphemes string
+	}{
+		{"וְ/הָ/אָ֖רֶץ", Academic, "wə hā ʾāreṣ"},
+		{"בְּ/רֵאשִׁ֖ית", Simple, "be reshit"},
+		//+ the ב follows the vowel of the conjunction, so it's spirant
+		{"וּ/בְ/י֥וֹם", Academic, "û ḇə yôm"},
+		{"לַ/יהוָ֑ה", Academic, "la YHWH"},
+		{"בָּרָ֣א", Academic, "bārāʾ"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if got := strings.Join(TransliterateMorphemes(test.text, test.style), " "); got != test.morphemes {
+				t.Errorf("got %q, want %q", got, test.morphemes)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/models"
+)
+
+const (
+	// GranularityWord saves one record per word
+	GranularityWord = "word"
+	// GranularityVerse saves one document per verse
+	GranularityVerse = "verse"
+	// GranularityChapter saves one document per chapter
+	GranularityChapter = "chapter"
+	// GranularityBook saves one document per book
+	GranularityBook = "book"
+)
+
+// Granularities lists the granularities in order of size
+var Granularities = []string{GranularityWord, GranularityVerse, GranularityChapter, GranularityBook}
+
+// ValidGranularity reports whether granularity is one of Granularities
+func ValidGranularity(granularity string) bool {
+	for _, g := range Granularities {
+		if g == granularity {
+			return true
+		}
+	}
+	return false
+}
+
+// newDocument starts the document that reference falls in
+func newDocument(granularity string, reference models.Reference) models.Document {
+	document := models.Document{
+		ID:          fmt.Sprintf("%02d", reference.Book),
+		Granularity: granularity,
+		Book:        reference.Book,
+		BookID:      reference.BookID,
+	}
+	switch granularity {
+	case GranularityVerse:
+		document.ID = reference.VerseID()
+		document.Chapter, document.Verse = reference.Chapter, reference.Verse
+	case GranularityChapter:
+		document.ID = fmt.Sprintf("%02d%03d", reference.Book, reference.Chapter)
+		document.Chapter = reference.Chapter
+	}
+	return document
+}
+
+// group splits words into runs that share a document. Words arrive in
+// order, so each document is one run.
+func group(granularity string, size int, reference func(i int) models.Reference) ([]models.Document, [][2]int) {
+	var documents []models.Document
+	var runs [][2]int
+	for i := 0; i < size; i++ {
+		document := newDocument(granularity, reference(i))
+		if len(documents) > 0 && documents[len(documents)-1].ID == document.ID {
+			runs[len(runs)-1][1] = i + 1
+			continue
+		}
+		documents = append(documents, document)
+		runs = append(runs, [2]int{i, i + 1})
+	}
+	return documents, runs
+}
+
+// wlcDocuments groups WLC words into documents. The text is rebuilt from
+// whole words even when the rows are morphemes.
+func wlcDocuments(words []corpus.WlcWord, options Options) []models.Document {
+	documents, runs := group(options.Granularity, len(words), func(i int) models.Reference {
+		return words[i].Reference
+	})
+	for i, run := range runs {
+		members := words[run[0]:run[1]]
+		var text []string
+		for _, word := range members {
+			text = append(text, word.Normalized)
+		}
+		documents[i].Text = strings.Join(text, " ")
+		if options.Morphemes {
+			documents[i].Words = corpus.MorphemeRows(members)
+			continue
+		}
+		documents[i].Words = members
+	}
+	return documents
+}
+
+// gntDocuments groups GNT words into documents, keeping their punctuation
+// in the text
+func gntDocuments(words []corpus.GntWord, options Options) []models.Document {
+	documents, runs := group(options.Granularity, len(words), func(i int) models.Reference {
+		return words[i].Reference
+	})
+	for i, run := range runs {
+		members := words[run[0]:run[1]]
+		var text []string
+		for _, word := range members {
+			text = append(text, word.Text)
+		}
+		documents[i].Text = strings.Join(text, " ")
+		documents[i].Words = members
+	}
+	return documents
+}
@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/davidbetz/morph/corpus"
+	"github.com/davidbetz/morph/internal/checkpoint"
+	"github.com/davidbetz/morph/internal/platform"
+	"github.com/davidbetz/morph/internal/retry"
+)
+
+// Options controls how parsed books are saved
+type Options struct {
+	TableName string
+	// Morphemes saves one WLC row per morpheme instead of per word
+	Morphemes bool
+	// Granularity groups the words into one document per verse, chapter
+	// or book. The default, GranularityWord, saves each word on its own.
+	Granularity string
+	// Checkpoint records progress and skips what an earlier run of the
+	// same import saved. It may be nil.
+	Checkpoint *checkpoint.Checkpoint
+	// Manifest is where the checkpoint writes its manifest once the run
+	// completes
+	Manifest string
+	// Concurrency is how many partitions of a book each sink that takes
+	// parallel writers saves at once
+	Concurrency int
+	// Retry is how the cloud sinks retry transient failures. The zero
+	// Policy means retry.Default.
+	Retry retry.Policy
+	// WritesPerSecond limits the records each cloud sink writes per
+	// second. Zero is unlimited.
+	WritesPerSecond float64
+}
+
+// sinkConfig is what each sink is opened with
+func (o Options) sinkConfig() platform.Config {
+	return platform.Config{
+		TableName:       o.TableName,
+		Checkpoint:      o.Checkpoint,
+		Concurrency:     o.Concurrency,
+		Retry:           o.Retry,
+		WritesPerSecond: o.WritesPerSecond,
+	}
+}
+
+func (o Options) documents() bool {
+	return len(o.Granularity) > 0 && o.Granularity != GranularityWord
+}
+
+// postPersist finishes the run unless parsing stopped early or was
+// cancelled. Parse errors
+// collected while continuing past them still let every good word be
+// finished and are returned afterwards. Only a clean run completes the
+// checkpoint.
+func postPersist(ctx context.Context, err error, post func(ctx context.Context) error, options Options) error {
+	var parseErrors corpus.ParseErrors
+	if err != nil && !errors.As(err, &parseErrors) {
+		return err
+	}
+	postErr := post(ctx)
+	if postErr != nil {
+		return postErr
+	}
+	//+ a sink that ignored the cancellation mustn't complete the checkpoint
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	return options.Checkpoint.Complete(options.Manifest)
+}
+
+// skip passes over the books every sink already saved
+func skip(options Options) func(name string) bool {
+	return func(name string) bool {
+		if !options.Checkpoint.Finished(name) {
+			return false
+		}
+		fmt.Printf("Skipping %s, already saved.\n", name)
+		return true
+	}
+}
+
+// Wlc parses the WLC and saves every book to sink. Once ctx is cancelled
+// the book being saved stops after its current partition and Wlc returns
+// ctx.Err(), leaving the checkpoint for -resume.
+func Wlc(ctx context.Context, c *corpus.Wlc, sink platform.Sink, options Options) error {
+	err := sink.Open(ctx, options.sinkConfig())
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	c.SkipBooks(skip(options))
+	err = c.BooksContext(ctx, func(book corpus.WlcBook) error {
+		if len(book.Words) == 0 {
+			return nil
+		}
+		fmt.Printf("Parsed %s. Saving...\n", book.Name)
+		if options.documents() {
+			return sink.PrepareAndPersistDocuments(ctx, book.Name, wlcDocuments(book.Words, options))
+		}
+		words := book.Words
+		if options.Morphemes {
+			words = corpus.MorphemeRows(words)
+		}
+		return sink.PrepareAndPersistWlc(ctx, book.Name, words)
+	})
+	if options.documents() {
+		return postPersist(ctx, err, sink.PostPersistDocuments, options)
+	}
+	return postPersist(ctx, err, sink.PostPersistWlc, options)
+}
+
+// Gnt parses MorphGNT and saves every book to sink, stopping as Wlc does
+// once ctx is cancelled
+func Gnt(ctx context.Context, c *corpus.Gnt, sink platform.Sink, options Options) error {
+	err := sink.Open(ctx, options.sinkConfig())
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+	c.SkipBooks(skip(options))
+	err = c.BooksContext(ctx, func(book corpus.GntBook) error {
+		if len(book.Words) == 0 {
+			return nil
+		}
+		fmt.Printf("Parsed %s. Saving...\n", book.Name)
+		if options.documents() {
+			return sink.PrepareAndPersistDocuments(ctx, book.Name, gntDocuments(book.Words, options))
+		}
+		return sink.PrepareAndPersistGnt(ctx, book.Name, book.Words)
+	})
+	if options.documents() {
+		return postPersist(ctx, err, sink.PostPersistDocuments, options)
+	}
+	return postPersist(ctx, err, sink.PostPersistGnt, options)
+}
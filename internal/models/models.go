@@ -1,13 +1,123 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Reference locates a word by canonical book number (1-66), chapter,
+// verse and word position within the verse
+type Reference struct {
+	Book    int    `json:"book"`
+	BookID  string `json:"bookId"`
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Word    int    `json:"word"`
+}
+
+// VerseID formats the verse as BBCCCVVV
+func (r Reference) VerseID() string {
+	return fmt.Sprintf("%02d%03d%03d", r.Book, r.Chapter, r.Verse)
+}
+
+// WordID packs the reference as BBCCCVVVWWW
+func (r Reference) WordID() int64 {
+	return int64(r.Book)*1000000000 + int64(r.Chapter)*1000000 + int64(r.Verse)*1000 + int64(r.Word)
+}
+
+// OSISID formats the verse as an OSIS reference such as Gen.1.1
+func (r Reference) OSISID() string {
+	return fmt.Sprintf("%s.%d.%d", r.BookID, r.Chapter, r.Verse)
+}
+
+// Strongs is a Strong's number with its optional disambiguation letter
+type Strongs struct {
+	Number   int    `json:"number"`
+	Letter   string `json:"letter,omitempty"`
+	Compound bool   `json:"compound,omitempty"`
+}
+
+// String formats the reference as H1254a
+func (s Strongs) String() string {
+	return fmt.Sprintf("H%d%s", s.Number, s.Letter)
+}
+
+// WlcMorphology is the decoded morphology of one WLC morpheme. Fields that
+// don't apply to the part of speech are empty.
+type WlcMorphology struct {
+	Language    string `json:"language,omitempty"`
+	Part        string `json:"part,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Stem        string `json:"stem,omitempty"`
+	Conjugation string `json:"conjugation,omitempty"`
+	Person      string `json:"person,omitempty"`
+	Gender      string `json:"gender,omitempty"`
+	Number      string `json:"number,omitempty"`
+	State       string `json:"state,omitempty"`
+}
+
+// String formats the morphology as key=value pairs in field order, such as
+// part=verb,stem=qal,conjugation=perfect (qatal),person=third
+func (m WlcMorphology) String() string {
+	var pairs []string
+	for _, field := range []struct{ name, value string }{
+		{"part", m.Part},
+		{"type", m.Type},
+		{"stem", m.Stem},
+		{"conjugation", m.Conjugation},
+		{"person", m.Person},
+		{"gender", m.Gender},
+		{"number", m.Number},
+		{"state", m.State},
+	} {
+		if len(field.value) > 0 {
+			pairs = append(pairs, field.name+"="+field.value)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+type WlcMorpheme struct {
+	Text       string        `json:"text"`
+	Lemma      string        `json:"lemma"`
+	Code       string        `json:"code"`
+	Prefix     string        `json:"prefix,omitempty"`
+	Strongs    *Strongs      `json:"strongs,omitempty"`
+	Morphology WlcMorphology `json:"morphology"`
+	Parsing    string        `json:"parsing,omitempty"`
+	//+ transliterations of Text, read in the context of the whole word
+	Transliteration       string `json:"transliteration,omitempty"`
+	SimpleTransliteration string `json:"simpleTransliteration,omitempty"`
+}
+
 type WlcWord struct {
-	Codes            string              `json:"codes"`
-	Language         string              `json:"language"`
-	Lemma            string              `json:"lemma"`
-	ID               string              `json:"coreid"`
-	Morphology       []map[string]string `json:"morphology"`
-	SequenceID       int64               `json:"id"`
-	Verse            string              `json:"verse"`
+	Codes       string `json:"codes"`
+	Language    string `json:"language"`
+	Lemma       string `json:"lemma"`
+	Consonantal string `json:"consonantal"`
+	Pointed     string `json:"pointed"`
+	Normalized  string `json:"normalized"`
+	//+ transliterations of Lemma in SBL academic and simple styles
+	Transliteration       string `json:"transliteration,omitempty"`
+	SimpleTransliteration string `json:"simpleTransliteration,omitempty"`
+	//+ the word's place in the division of the verse by its accents
+	Accent           string          `json:"accent,omitempty"`
+	Depth            int             `json:"depth"`
+	Half             int             `json:"half"`
+	Segment          string          `json:"segment"`
+	ID               string          `json:"coreid"`
+	Prefixes         []string        `json:"prefixes,omitempty"`
+	Strongs          *Strongs        `json:"strongs,omitempty"`
+	StrongsID        string          `json:"strongsId,omitempty"`
+	Morphology       []WlcMorphology `json:"morphology"`
+	Morphemes        []WlcMorpheme   `json:"morphemes,omitempty"`
+	Parsing          string          `json:"parsing,omitempty"`
+	Morpheme         int             `json:"morpheme,omitempty"`
+	SequenceID       int64           `json:"id"`
+	Verse            string          `json:"verse"`
+	Reference        Reference       `json:"reference"`
+	EnglishVerse     string          `json:"englishVerse,omitempty"`
+	EnglishReference *Reference      `json:"englishReference,omitempty"`
 	MorphologyString string
 }
 
@@ -23,13 +133,62 @@ type GntMorphology struct {
 	Degree string `json:"degree,omitempty"`
 }
 
+// String formats the morphology as key=value pairs in field order
+func (m GntMorphology) String() string {
+	var pairs []string
+	for _, field := range []struct{ name, value string }{
+		{"part", m.Part},
+		{"person", m.Person},
+		{"tense", m.Tense},
+		{"voice", m.Voice},
+		{"mood", m.Mood},
+		{"case", m.Case},
+		{"number", m.Number},
+		{"gender", m.Gender},
+		{"degree", m.Degree},
+	} {
+		if len(field.value) > 0 {
+			pairs = append(pairs, field.name+"="+field.value)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
 type GntWord struct {
 	Verse      string        `json:"verse"`
 	ID         int64         `json:"id"`
+	Reference  Reference     `json:"reference"`
 	Codes      string        `json:"codes"`
 	Morphology GntMorphology `json:"morphology"`
+	Parsing    string        `json:"parsing,omitempty"`
 	Text       string        `json:"text"`
 	Word       string        `json:"word"`
 	Normalized string        `json:"normalized"`
 	Lemma      string        `json:"lemma"`
+	//+ punctuation attached to Text, and the IDs of the first words of the
+	//+ sentence and clause the word belongs to
+	Leading  string `json:"leading,omitempty"`
+	Trailing string `json:"trailing,omitempty"`
+	Sentence int64  `json:"sentence"`
+	Clause   int64  `json:"clause"`
+	//+ generated search and alternate-script forms of Word and Lemma
+	Folded               string `json:"folded"`
+	LemmaFolded          string `json:"lemmaFolded"`
+	BetaCode             string `json:"betaCode"`
+	LemmaBetaCode        string `json:"lemmaBetaCode"`
+	Transliteration      string `json:"transliteration"`
+	LemmaTransliteration string `json:"lemmaTransliteration"`
+}
+
+// Document holds the words of one verse, chapter or book in order with the
+// text they make up. Words is a []WlcWord or []GntWord.
+type Document struct {
+	ID          string      `json:"id"`
+	Granularity string      `json:"granularity"`
+	Book        int         `json:"book"`
+	BookID      string      `json:"bookId"`
+	Chapter     int         `json:"chapter,omitempty"`
+	Verse       int         `json:"verse,omitempty"`
+	Text        string      `json:"text"`
+	Words       interface{} `json:"words"`
 }
@@ -0,0 +1,423 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// reverseLookup finds the letter a lookup table decodes to value
+func reverseLookup(lookup map[string]string, value string) (string, bool) {
+	for letter, v := range lookup {
+		if strings.EqualFold(v, value) {
+			return letter, true
+		}
+	}
+	return "", false
+}
+
+// getField returns the morphology field a tree node is named after
+func getField(m models.WlcMorphology, name string) string {
+	switch name {
+	case "Type":
+		return m.Type
+	case "Stem":
+		return m.Stem
+	case "Conjugation":
+		return m.Conjugation
+	case "Person":
+		return m.Person
+	case "Gender":
+		return m.Gender
+	case "Number":
+		return m.Number
+	case "State":
+		return m.State
+	}
+	return ""
+}
+
+// Decode parses an OSHB morphology code such as HVqp3ms
+func (t *Wlc) Decode(code string) ([]models.WlcMorphology, error) {
+	_, morphology, err := t.parseMorphology(code)
+	return morphology, err
+}
+
+// Encode builds the OSHB code for the morphology of each morpheme of a
+// word. Decoding the result gives back the same morphology, so fields that
+// don't apply to a part of speech are rejected.
+func (t *Wlc) Encode(morphology []models.WlcMorphology) (string, error) {
+	if len(morphology) == 0 {
+		return "", errors.New("no morphology to encode")
+	}
+	language := morphology[0].Language
+	if len(language) == 0 {
+		language = t.languageLookup["H"]
+	}
+	languageCode, ok := reverseLookup(t.languageLookup, language)
+	if !ok {
+		return "", fmt.Errorf("unknown language %q", language)
+	}
+	var parts []string
+	for _, m := range morphology {
+		if len(m.Language) > 0 && !strings.EqualFold(m.Language, language) {
+			return "", fmt.Errorf("morphemes mix %s and %s", language, m.Language)
+		}
+		part, err := t.encodePart(languageCode, m)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	code := languageCode + strings.Join(parts, morphemeSeparator)
+	decoded, err := t.Decode(code)
+	if err != nil {
+		return "", err
+	}
+	for i, m := range morphology {
+		m.Language = decoded[i].Language
+		if m != decoded[i] {
+			return "", fmt.Errorf("%s has fields that don't apply to a %s", m.String(), m.Part)
+		}
+	}
+	return code, nil
+}
+
+func (t *Wlc) tree(languageCode string, partCode string) *node {
+	if partCode == "V" {
+		return t.trees[partCode+languageCode]
+	}
+	return t.trees[partCode]
+}
+
+func (t *Wlc) encodePart(languageCode string, m models.WlcMorphology) (string, error) {
+	partCode, ok := reverseLookup(t.partOfSpeechLookup, m.Part)
+	if !ok {
+		return "", fmt.Errorf("unknown part of speech %q", m.Part)
+	}
+	code := partCode
+	//+ placeholders are only written when a later letter follows them
+	pending := ""
+	for tree := t.tree(languageCode, partCode); tree != nil && tree.Lookup != nil; {
+		letter := "x"
+		if value := getField(m, tree.Name); len(value) > 0 {
+			letter, ok = reverseLookup(tree.Lookup, value)
+			if !ok {
+				return "", fmt.Errorf("unknown %s %q for a %s", strings.ToLower(tree.Name), value, m.Part)
+			}
+			code += pending + letter
+			pending = ""
+		} else {
+			pending += letter
+		}
+		if tree.Decider != nil && !tree.Decider(letter) {
+			tree = tree.Alternate
+		} else {
+			tree = tree.Next
+		}
+	}
+	return code, nil
+}
+
+// fields lists every field a tree can decode, with the values each takes
+func (n *node) fields(fields map[string]map[string]string) map[string]map[string]string {
+	if n == nil {
+		return fields
+	}
+	if n.Lookup != nil {
+		if fields[n.Name] == nil {
+			fields[n.Name] = make(map[string]string)
+		}
+		for letter, value := range n.Lookup {
+			fields[n.Name][letter] = value
+		}
+	}
+	n.Next.fields(fields)
+	n.Alternate.fields(fields)
+	return fields
+}
+
+type candidate struct {
+	field string
+	value string
+}
+
+// phrases maps the words of a description to the fields they could set
+type phrases map[string][]candidate
+
+func (p phrases) add(field string, lookup map[string]string) {
+	for _, value := range lookup {
+		aliases := []string{value}
+		if i := strings.Index(value, " ("); i > 0 {
+			aliases = append(aliases, value[:i])
+			if field == "Conjugation" {
+				aliases = append(aliases, strings.Trim(value[i+2:], ")"))
+			}
+		}
+		for _, alias := range aliases {
+			alias = strings.ToLower(alias)
+			found := false
+			for _, c := range p[alias] {
+				found = found || (c.field == field && c.value == value)
+			}
+			if !found {
+				p[alias] = append(p[alias], candidate{field, value})
+			}
+		}
+	}
+}
+
+// match finds the longest phrase starting at the first word
+func (p phrases) match(words []string) ([]candidate, int) {
+	for n := 3; n > 0; n-- {
+		if n > len(words) {
+			continue
+		}
+		if candidates, ok := p[strings.Join(words[:n], " ")]; ok {
+			return candidates, n
+		}
+	}
+	return nil, 0
+}
+
+var wlcShorthandRe = regexp.MustCompile(`^([123])?([bcfm])([dps])([acd])?$`)
+
+// ParseDescription reads morphology from text such as "Hebrew qal perfect
+// 3ms" or "conjunction / qal wayyiqtol 3ms", with one slash-separated
+// description per morpheme. It also accepts the key=value form of
+// MorphologyString, e.g. "part=verb,stem=qal,conjugation=perfect (qatal)".
+func (t *Wlc) ParseDescription(text string) ([]models.WlcMorphology, error) {
+	if strings.Contains(text, "=") {
+		return t.parseKeyValues(text)
+	}
+	p := make(phrases)
+	p.add("Language", t.languageLookup)
+	p.add("Part", t.partOfSpeechLookup)
+	for _, lookup := range []map[string]string{t.adjectiveLookup, t.nounLookup, t.pronounLookup, t.prepositionLookup, t.suffixLookup, t.particleLookup} {
+		p.add("Type", lookup)
+	}
+	p.add("Stem", t.hebrewStemLookup)
+	p.add("Stem", t.aramaicVerbLookup)
+	p.add("Conjugation", t.verbConjugationTypesLookup)
+	p.add("Person", t.hebrewPersonLookup)
+	p.add("Gender", t.hebrewGenderLookup)
+	p.add("Number", t.hebrewNumberLookup)
+	p.add("State", t.stateLookup)
+	var matches [][][]candidate
+	language := ""
+	for _, segment := range strings.Split(text, morphemeSeparator) {
+		var found [][]candidate
+		words := strings.Fields(strings.ToLower(strings.Replace(segment, ",", " ", -1)))
+		for len(words) > 0 {
+			if match := wlcShorthandRe.FindStringSubmatch(words[0]); match != nil {
+				letters := []struct {
+					field  string
+					letter string
+					lookup map[string]string
+				}{
+					{"Person", match[1], t.hebrewPersonLookup},
+					{"Gender", match[2], t.hebrewGenderLookup},
+					{"Number", match[3], t.hebrewNumberLookup},
+					{"State", match[4], t.stateLookup},
+				}
+				for _, l := range letters {
+					if len(l.letter) > 0 {
+						found = append(found, []candidate{{l.field, l.lookup[l.letter]}})
+					}
+				}
+				words = words[1:]
+				continue
+			}
+			candidates, n := p.match(words)
+			if n == 0 {
+				return nil, fmt.Errorf("unknown word %q", words[0])
+			}
+			if candidates[0].field == "Language" {
+				language = candidates[0].value
+			} else {
+				found = append(found, candidates)
+			}
+			words = words[n:]
+		}
+		matches = append(matches, found)
+	}
+	var morphology []models.WlcMorphology
+	for _, found := range matches {
+		m, err := t.resolve(language, found)
+		if err != nil {
+			return nil, err
+		}
+		morphology = append(morphology, m)
+	}
+	return morphology, nil
+}
+
+// resolve picks the field each described word sets, given the fields the
+// part of speech allows
+func (t *Wlc) resolve(language string, found [][]candidate) (models.WlcMorphology, error) {
+	m := models.WlcMorphology{Language: language}
+	for _, candidates := range found {
+		for _, c := range candidates {
+			switch c.field {
+			case "Part":
+				m.Part = c.value
+			case "Stem", "Conjugation":
+				if len(m.Part) == 0 {
+					m.Part = t.partOfSpeechLookup["V"]
+				}
+				if len(language) == 0 {
+					if _, ok := reverseLookup(t.hebrewStemLookup, c.value); !ok && c.field == "Stem" {
+						m.Language = t.languageLookup["A"]
+					}
+				}
+			}
+		}
+	}
+	if len(m.Part) == 0 {
+		return m, errors.New("part of speech required, e.g. noun, verb or particle")
+	}
+	if len(m.Language) == 0 {
+		m.Language = t.languageLookup["H"]
+	}
+	languageCode, _ := reverseLookup(t.languageLookup, m.Language)
+	partCode, _ := reverseLookup(t.partOfSpeechLookup, m.Part)
+	fields := t.tree(languageCode, partCode).fields(make(map[string]map[string]string))
+	for _, candidates := range found {
+		if candidates[0].field == "Part" {
+			continue
+		}
+		set := false
+		for _, c := range candidates {
+			if _, ok := reverseLookup(fields[c.field], c.value); !ok || len(getField(m, c.field)) > 0 {
+				continue
+			}
+			setField(&m, c.field, c.value)
+			set = true
+			break
+		}
+		if !set {
+			return m, fmt.Errorf("%q doesn't apply to a %s", candidates[0].value, m.Part)
+		}
+	}
+	return m, nil
+}
+
+func (t *Wlc) parseKeyValues(text string) ([]models.WlcMorphology, error) {
+	var morphology []models.WlcMorphology
+	language := ""
+	for _, group := range strings.Split(text, "|") {
+		var m models.WlcMorphology
+		for _, pair := range strings.Split(group, ",") {
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("expected key=value but found %q", pair)
+			}
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			value := strings.TrimSpace(kv[1])
+			switch key {
+			case "language":
+				language = value
+			case "part":
+				m.Part = value
+			case "type", "stem", "conjugation", "person", "gender", "number", "state":
+				setField(&m, strings.ToUpper(key[:1])+key[1:], value)
+			default:
+				return nil, fmt.Errorf("unknown field %q", key)
+			}
+		}
+		morphology = append(morphology, m)
+	}
+	//+ the language belongs to the whole word, so it may be given once
+	for i := range morphology {
+		morphology[i].Language = language
+	}
+	return morphology, nil
+}
+
+// Decode parses a MorphGNT part such as V- and code such as 3AAI-S--
+func (t *Gnt) Decode(part string, code string) (models.GntMorphology, error) {
+	if err := t.validateCode(code); err != nil {
+		return models.GntMorphology{}, err
+	}
+	return t.getMorphology(part, code)
+}
+
+// Encode builds the MorphGNT part and code for a morphology
+func (t *Gnt) Encode(m models.GntMorphology) (string, string, error) {
+	part, ok := reverseLookup(t.partLookup, m.Part)
+	if !ok {
+		return "", "", fmt.Errorf("unknown part of speech %q", m.Part)
+	}
+	code := ""
+	for _, position := range t.positionLookups() {
+		value := position.get(m)
+		if len(value) == 0 {
+			code += "-"
+			continue
+		}
+		letter, ok := reverseLookup(position.values, value)
+		if !ok {
+			return "", "", fmt.Errorf("unknown %s %q", position.name, value)
+		}
+		code += letter
+	}
+	return part, code, nil
+}
+
+var (
+	gntPersonNumberRe     = regexp.MustCompile(`^([123])([sp])$`)
+	gntCaseNumberGenderRe = regexp.MustCompile(`^([ngda])([sp])([mfn])?$`)
+)
+
+// ParseDescription reads morphology from text such as "verb aorist active
+// indicative 3s" or "noun nsf"
+func (t *Gnt) ParseDescription(text string) (models.GntMorphology, error) {
+	var m models.GntMorphology
+	p := make(phrases)
+	p.add("part", t.partLookup)
+	for _, position := range t.positionLookups() {
+		p.add(position.name, position.values)
+	}
+	positions := make(map[string]gntPosition)
+	for _, position := range t.positionLookups() {
+		positions[position.name] = position
+	}
+	set := func(field string, value string) {
+		if field == "part" {
+			m.Part = value
+			return
+		}
+		positions[field].set(&m, value)
+	}
+	words := strings.Fields(strings.ToLower(strings.Replace(text, ",", " ", -1)))
+	for len(words) > 0 {
+		if match := gntPersonNumberRe.FindStringSubmatch(words[0]); match != nil {
+			set("person", t.personLookup[match[1]])
+			set("number", t.numberLookup[strings.ToUpper(match[2])])
+			words = words[1:]
+			continue
+		}
+		if match := gntCaseNumberGenderRe.FindStringSubmatch(words[0]); match != nil {
+			set("case", t.caseLookup[strings.ToUpper(match[1])])
+			set("number", t.numberLookup[strings.ToUpper(match[2])])
+			if len(match[3]) > 0 {
+				set("gender", t.genderLookup[strings.ToUpper(match[3])])
+			}
+			words = words[1:]
+			continue
+		}
+		candidates, n := p.match(words)
+		if n == 0 {
+			return m, fmt.Errorf("unknown word %q", words[0])
+		}
+		set(candidates[0].field, candidates[0].value)
+		words = words[n:]
+	}
+	if len(m.Part) == 0 {
+		return m, errors.New("part of speech required")
+	}
+	return m, nil
+}
@@ -0,0 +1,272 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// wlcCodes collects every distinct morphology code in the shipped WLC trees
+func wlcCodes(t *testing.T) []string {
+	files, err := filepath.Glob(filepath.Join("..", "..", "morphwlc", "*", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no WLC files under morphwlc")
+	}
+	seen := make(map[string]bool)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var chapters [][][][]string
+		err = json.Unmarshal(content, &chapters)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		for _, verses := range chapters {
+			for _, words := range verses {
+				for _, word := range words {
+					if len(word) > 2 {
+						seen[word[2]] = true
+					}
+				}
+			}
+		}
+	}
+	var codes []string
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestWlcCodesRoundTrip(t *testing.T) {
+	wlc := CreateWlc("")
+	codes := wlcCodes(t)
+	for _, code := range codes {
+		morphology, err := wlc.Decode(code)
+		if err != nil {
+			t.Errorf("decode %s: %s", code, err)
+			continue
+		}
+		encoded, err := wlc.Encode(morphology)
+		if err != nil {
+			t.Errorf("encode %s: %s", code, err)
+			continue
+		}
+		if encoded != code {
+			t.Errorf("%s encodes back as %s", code, encoded)
+		}
+	}
+	t.Logf("%d distinct codes", len(codes))
+}
+
+// TestGntCodesRoundTrip reads a MorphGNT checkout at ./morphgnt, where the
+// README clones it; the repo doesn't ship one
+func TestGntCodesRoundTrip(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join("..", "..", "morphgnt", "*.txt"))
+	if len(files) == 0 {
+		t.Skip("no MorphGNT checkout at morphgnt")
+	}
+	gnt := CreateGnt()
+	seen := make(map[string]bool)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			parts := strings.Split(scanner.Text(), " ")
+			if len(parts) != 7 || seen[parts[1]+" "+parts[2]] {
+				continue
+			}
+			seen[parts[1]+" "+parts[2]] = true
+			morphology, err := gnt.Decode(parts[1], parts[2])
+			if err != nil {
+				t.Errorf("decode %s %s: %s", parts[1], parts[2], err)
+				continue
+			}
+			part, code, err := gnt.Encode(morphology)
+			if err != nil {
+				t.Errorf("encode %s %s: %s", parts[1], parts[2], err)
+				continue
+			}
+			if part != parts[1] || code != parts[2] {
+				t.Errorf("%s %s encodes back as %s %s", parts[1], parts[2], part, code)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Logf("%d distinct codes", len(seen))
+}
+
+// TestGntLettersRoundTrip covers every letter of the tag set, one position
+// at a time, whether or not a checkout is present
+func TestGntLettersRoundTrip(t *testing.T) {
+	gnt := CreateGnt()
+	for part := range gnt.partLookup {
+		for i, position := range gnt.positionLookups() {
+			for letter := range position.values {
+				code := strings.Repeat("-", i) + letter + strings.Repeat("-", 7-i)
+				morphology, err := gnt.Decode(part, code)
+				if err != nil {
+					t.Errorf("decode %s %s: %s", part, code, err)
+					continue
+				}
+				encodedPart, encoded, err := gnt.Encode(morphology)
+				if err != nil || encodedPart != part || encoded != code {
+					t.Errorf("%s %s encodes back as %s %s (%v)", part, code, encodedPart, encoded, err)
+				}
+			}
+		}
+	}
+}
+
+func TestWlcEncode(t *testing.T) {
+	wlc := CreateWlc("")
+	tests := []struct {
+		name       string
+		morphology []models.WlcMorphology
+		code       string
+		err        string
+	}{
+		{"empty", nil, "", "no morphology to encode"},
+		{"language defaults to Hebrew", []models.WlcMorphology{{Part: "verb", Stem: "qal", Conjugation: "perfect (qatal)", Person: "third", Gender: "masculine", Number: "singular"}}, "HVqp3ms", ""},
+		{"trailing placeholders dropped", []models.WlcMorphology{{Language: "Hebrew", Part: "verb", Stem: "qal"}}, "HVq", ""},
+		{"inner placeholders kept", []models.WlcMorphology{{Language: "Hebrew", Part: "verb", Conjugation: "perfect (qatal)"}}, "HVxp", ""},
+		{"prefixes", []models.WlcMorphology{{Part: "conjunction"}, {Part: "verb", Stem: "qal", Conjugation: "sequential imperfect (wayyiqtol)", Person: "third", Gender: "masculine", Number: "singular"}}, "HC/Vqw3ms", ""},
+		{"Aramaic verb tree", []models.WlcMorphology{{Language: "Aramaic", Part: "verb", Stem: "peal", Conjugation: "perfect (qatal)", Person: "third", Gender: "masculine", Number: "singular"}}, "AVqp3ms", ""},
+		{"unknown language", []models.WlcMorphology{{Language: "Greek", Part: "noun"}}, "", `unknown language "Greek"`},
+		{"mixed languages", []models.WlcMorphology{{Language: "Hebrew", Part: "conjunction"}, {Language: "Aramaic", Part: "noun"}}, "", "morphemes mix Hebrew and Aramaic"},
+		{"unknown part", []models.WlcMorphology{{Part: "gerund"}}, "", `unknown part of speech "gerund"`},
+		{"unknown value", []models.WlcMorphology{{Part: "verb", Stem: "qal", Conjugation: "aorist"}}, "", `unknown conjugation "aorist" for a verb`},
+		{"field that doesn't apply", []models.WlcMorphology{{Part: "conjunction", Person: "third"}}, "", "has fields that don't apply to a conjunction"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := wlc.Encode(test.morphology)
+			checkResult(t, code, err, test.code, test.err)
+		})
+	}
+}
+
+func TestWlcParseDescription(t *testing.T) {
+	wlc := CreateWlc("")
+	tests := []struct {
+		text string
+		code string
+		err  string
+	}{
+		{"Hebrew qal perfect 3ms", "HVqp3ms", ""},
+		{"qal perfect 3ms", "HVqp3ms", ""},
+		{"QAL, Perfect, 3ms", "HVqp3ms", ""},
+		{"peal perfect 3ms", "AVqp3ms", ""},
+		{"perfect 3ms", "HVxp3ms", ""},
+		{"conjunction / qal wayyiqtol 3ms", "HC/Vqw3ms", ""},
+		{"preposition / suffix pronominal 2mp", "HR/Sp2mp", ""},
+		{"pronoun personal 3ms", "HPp3ms", ""},
+		{"noun common masculine singular absolute", "HNcmsa", ""},
+		{"Aramaic noun common masculine singular absolute", "ANcmsa", ""},
+		{"part=verb,stem=qal,conjugation=perfect (qatal)", "HVqp", ""},
+		{"language=Hebrew,part=conjunction|part=verb,stem=qal", "HC/Vq", ""},
+		{"frobnicate", "", `unknown word "frobnicate"`},
+		{"masculine singular", "", "part of speech required"},
+		{"noun qal", "", `"qal" doesn't apply to a noun`},
+		{"part=verb,bogus=1", "", `unknown field "bogus"`},
+		{"part=verb,stem", "", `expected key=value but found "stem"`},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			morphology, err := wlc.ParseDescription(test.text)
+			code := ""
+			if err == nil {
+				code, err = wlc.Encode(morphology)
+			}
+			checkResult(t, code, err, test.code, test.err)
+		})
+	}
+}
+
+func TestGntEncode(t *testing.T) {
+	gnt := CreateGnt()
+	tests := []struct {
+		name       string
+		morphology models.GntMorphology
+		code       string
+		err        string
+	}{
+		{"verb", models.GntMorphology{Part: "verb", Person: "third", Tense: "aorist", Voice: "active", Mood: "indicative", Number: "singular"}, "V- 3AAI-S--", ""},
+		{"no fields", models.GntMorphology{Part: "particle"}, "X- --------", ""},
+		{"unknown part", models.GntMorphology{Part: "gerund"}, "", `unknown part of speech "gerund"`},
+		{"unknown value", models.GntMorphology{Part: "verb", Tense: "pluperfect perfect"}, "", `unknown tense "pluperfect perfect"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			part, code, err := gnt.Encode(test.morphology)
+			checkResult(t, part+" "+code, err, test.code, test.err)
+		})
+	}
+}
+
+func TestGntParseDescription(t *testing.T) {
+	gnt := CreateGnt()
+	tests := []struct {
+		text string
+		code string
+		err  string
+	}{
+		{"verb aorist active indicative 3s", "V- 3AAI-S--", ""},
+		{"Verb, Aorist, Active, Indicative, 3S", "V- 3AAI-S--", ""},
+		{"noun nsf", "N- ----NSF-", ""},
+		{"adjective asm comparative", "A- ----ASMC", ""},
+		{"verb present active participle nsm", "V- -PAPNSM-", ""},
+		{"relative pronoun gpm", "RR ----GPM-", ""},
+		{"3s", "", "part of speech required"},
+		{"noun xyz", "", `unknown word "xyz"`},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			morphology, err := gnt.ParseDescription(test.text)
+			code := ""
+			if err == nil {
+				var part string
+				part, code, err = gnt.Encode(morphology)
+				code = part + " " + code
+			}
+			checkResult(t, code, err, test.code, test.err)
+		})
+	}
+}
+
+// checkResult compares a code against the one wanted, or an error against
+// the text it should contain when wantErr isn't empty
+func checkResult(t *testing.T, code string, err error, want string, wantErr string) {
+	t.Helper()
+	if len(wantErr) > 0 {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("got %q, %v; want error containing %q", code, err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if code != want {
+		t.Errorf("got %s, want %s", code, want)
+	}
+}
@@ -0,0 +1,191 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/davidbetz/morph/internal/canon"
+)
+
+// errSkip is returned for a file that isn't part of the corpus
+var errSkip = errors.New("skip")
+
+// ParseError is a problem in the source text together with where it was found
+type ParseError struct {
+	File    string `json:"file,omitempty"`
+	Book    string `json:"book,omitempty"`
+	Chapter int    `json:"chapter,omitempty"`
+	Verse   int    `json:"verse,omitempty"`
+	Word    int    `json:"word,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Err     error  `json:"-"`
+}
+
+func (e *ParseError) Error() string {
+	var location []string
+	if len(e.File) > 0 {
+		location = append(location, e.File)
+	}
+	if len(e.Book) > 0 {
+		location = append(location, e.Book)
+	}
+	if e.Chapter > 0 {
+		location = append(location, fmt.Sprintf("%d:%d", e.Chapter, e.Verse))
+	}
+	if e.Word > 0 {
+		location = append(location, fmt.Sprintf("word %d", e.Word))
+	}
+	message := e.Err.Error()
+	if len(e.Code) > 0 {
+		message = fmt.Sprintf("%s in %q", message, e.Code)
+	}
+	if len(location) == 0 {
+		return message
+	}
+	return strings.Join(location, " ") + ": " + message
+}
+
+// MarshalJSON includes the underlying error as a message field
+func (e *ParseError) MarshalJSON() ([]byte, error) {
+	type location ParseError
+	return json.Marshal(struct {
+		*location
+		Message string `json:"message"`
+	}{(*location)(e), e.Err.Error()})
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors is every problem collected when continuing past errors
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	var lines []string
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return fmt.Sprintf("%d parse errors:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// place identifies where a problem is, leaving out the file so the same
+// defect in the hebrew and remapped trees is one problem
+func (e *ParseError) place() string {
+	return fmt.Sprintf("%s %d:%d.%d %q %s", e.Book, e.Chapter, e.Verse, e.Word, e.Code, e.Err.Error())
+}
+
+// Unique drops every problem found at the same place as an earlier one
+func (e ParseErrors) Unique() ParseErrors {
+	seen := make(map[string]bool, len(e))
+	unique := ParseErrors{}
+	for _, err := range e {
+		if seen[err.place()] {
+			continue
+		}
+		seen[err.place()] = true
+		unique = append(unique, err)
+	}
+	return unique
+}
+
+func codeErrorf(code string, format string, args ...interface{}) *ParseError {
+	return &ParseError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// locate fills in where err happened, wrapping it in a ParseError if needed
+func locate(err error, file string, book string, chapter int, verse int, word int) *ParseError {
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		parseError = &ParseError{Err: err}
+	}
+	if len(parseError.File) == 0 {
+		parseError.File = file
+	}
+	if len(parseError.Book) == 0 {
+		parseError.Book = book
+	}
+	if parseError.Chapter == 0 {
+		parseError.Chapter = chapter
+		parseError.Verse = verse
+		parseError.Word = word
+	}
+	return parseError
+}
+
+// problems collects parse errors when continuing past them. Books are
+// parsed concurrently, so errors are collected under a lock.
+type problems struct {
+	continueOnError bool
+	strict          bool
+	mu              sync.Mutex
+	errors          ParseErrors
+	seen            map[string]bool
+}
+
+// reset forgets the problems of an earlier run
+func (p *problems) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors = nil
+	p.seen = nil
+}
+
+// report returns err when stopping at the first error, or records it and
+// returns nil so the caller skips the offending word
+func (p *problems) report(err *ParseError) error {
+	if !p.continueOnError {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	//+ -style both reads the same defect from both trees
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+	if p.seen[err.place()] {
+		return nil
+	}
+	p.seen[err.place()] = true
+	p.errors = append(p.errors, err)
+	return nil
+}
+
+// result returns the collected errors in canonical book order, or nil if
+// there were none
+func (p *problems) result() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(p.errors, func(i, j int) bool {
+		return bookNumber(p.errors[i].Book) < bookNumber(p.errors[j].Book)
+	})
+	return p.errors
+}
+
+func bookNumber(name string) int {
+	book, err := canon.LookupBook(name)
+	if err != nil {
+		return 0
+	}
+	return book.Number
+}
+
+// ContinueOnError collects parse errors into a ParseErrors report returned
+// once every book is read, instead of stopping at the first one
+func (p *problems) ContinueOnError(enabled bool) {
+	p.continueOnError = enabled
+}
+
+// Strict reports problems that parsing otherwise tolerates: codes with
+// letters the grammar doesn't define, morphemes that don't line up,
+// empty verses and malformed text
+func (p *problems) Strict(enabled bool) {
+	p.strict = enabled
+}
@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProblemsReportEachPlaceOnce(t *testing.T) {
+	var p problems
+	p.ContinueOnError(true)
+	for _, file := range []string{"hebrew/judges.json", "remapped/judges.json"} {
+		p.report(&ParseError{File: file, Book: "Judges", Chapter: 13, Verse: 14, Word: 18, Err: errors.New("bad")})
+	}
+	var errs ParseErrors
+	if !errors.As(p.result(), &errs) || len(errs) != 1 {
+		t.Fatalf("got %v, want one problem", p.result())
+	}
+	p.reset()
+	if err := p.result(); err != nil {
+		t.Errorf("after reset got %v, want nil", err)
+	}
+}
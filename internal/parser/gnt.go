@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -8,77 +10,81 @@ import (
 	"regexp"
 	"strconv"
 
+	"github.com/davidbetz/morph/internal/canon"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/platform"
-	"github.com/davidbetz/morph/internal/util"
 )
 
-type gntBookData struct {
+// SBLGNT files are numbered from 61 (Matthew) rather than the canonical 40
+const sblgntOffset = 21
+
+// GntBook is a parsed GNT book
+type GntBook struct {
 	Name string
 	Data []models.GntWord
 }
 
-func (t *Gnt) getBookNumber(filename string) int {
+func (t *Gnt) getBookNumber(filename string) (int, error) {
 	r, _ := regexp.Compile("([0-9]+)-([a-zA-Z0-9]+)-morphgnt")
 	results := r.FindStringSubmatch(filename)
 	if len(results) < 2 {
-		util.Errorf("Invalid filename " + filename)
+		return 0, &ParseError{File: filename, Err: errors.New("invalid filename")}
 	}
 	bookNumber, err := strconv.ParseInt(results[1], 10, 32)
 	if err != nil {
-		util.Errorf(err.Error())
+		return 0, &ParseError{File: filename, Err: err}
 	}
-	return int(bookNumber)
+	return int(bookNumber), nil
 }
 
-func (t *Gnt) readData(books chan *gntBookData) {
-	folder := os.Getenv("SOURCE")
-	if len(folder) == 0 {
-		folder = "./morphgnt/"
-	}
+// Books parses each book under folder and passes it to fn, stopping before
+// the next book once ctx is cancelled
+func (t *Gnt) Books(ctx context.Context, folder string, fn func(book *GntBook) error) error {
+	t.reset()
 	files, err := os.ReadDir(folder)
 	if err != nil {
-		util.Errorf(err.Error())
+		return err
 	}
+	var books []canon.Book
+	var filenames []string
 	for _, f := range files {
 		filename := f.Name()
-		bookName := filename[0 : len(filename)-len(filepath.Ext(filename))]
-		words, err := t.ParseFileContent(path.Join(folder, filename))
+		if filepath.Ext(filename) != ".txt" {
+			continue
+		}
+		bookNumber, err := t.getBookNumber(filename)
 		if err != nil {
-			if err.Error() == "Skip" {
-				continue
-			}
-			util.Errorf(err.Error())
+			return err
 		}
-		books <- &gntBookData{
-			bookName,
-			words,
+		book, ok := canon.BookByNumber(bookNumber - sblgntOffset)
+		if !ok {
+			return &ParseError{File: filename, Err: fmt.Errorf("invalid book number %d", bookNumber)}
 		}
-	}
-	close(books)
-}
-
-func (t *Gnt) getTableName() string {
-	tableName := os.Getenv("TABLE_NAME")
-	if len(tableName) == 0 {
-		tableName = "morphgnt"
-	}
-	return tableName
-}
-
-func (t *Gnt) Process() error {
-	books := make(chan *gntBookData)
-	go t.readData(books)
-	for book := range books {
-		if book == nil {
-			return nil
+		if !t.selection.IncludesBook(book.Number) {
+			continue
 		}
-		fmt.Printf("Parsed %s. Saving...\n", book.Name)
-		name := t.bookNames[t.getBookNumber(book.Name)]
-		err := platform.PrepareAndPersistGnt(t.getTableName(), name, book.Data)
+		if t.skip != nil && t.skip(book.Name) {
+			continue
+		}
+		books = append(books, book)
+		filenames = append(filenames, filename)
+	}
+	err = t.each(ctx, len(books), func(i int) (interface{}, error) {
+		words, err := t.ParseFileContent(path.Join(folder, filenames[i]))
 		if err != nil {
-			return err
+			if errors.Is(err, errSkip) {
+				return nil, nil
+			}
+			return nil, err
 		}
+		return &GntBook{
+			books[i].Name,
+			words,
+		}, nil
+	}, func(book interface{}) error {
+		return fn(book.(*GntBook))
+	})
+	if err != nil {
+		return err
 	}
-	return platform.PostPersistWLC(t.getTableName())
+	return t.result()
 }
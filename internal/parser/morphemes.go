@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/davidbetz/morph/internal/hebrew"
+	"github.com/davidbetz/morph/internal/models"
+)
+
+const morphemeSeparator = "/"
+
+func segment(segments []string, i int) string {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return ""
+}
+
+// hasLemma reports whether the morpheme at i has a lemma segment. Suffixes
+// don't, and neither does the Aramaic emphatic ending, which is tagged as
+// a trailing article.
+func hasLemma(languageCode string, parts []string, i int) bool {
+	if strings.HasPrefix(parts[i], "S") {
+		return false
+	}
+	return !(languageCode == "A" && i > 0 && i == len(parts)-1 && parts[i] == "Td")
+}
+
+// splitMorphemes aligns the slash-separated surface, lemma and morphology
+// segments of a word. Suffixes carry no lemma segment in the source, so
+// lemmas are consumed only by the non-suffix morphemes.
+func splitMorphemes(surface string, lemma string, codes string, morphologyArray []models.WlcMorphology) []models.WlcMorpheme {
+	if len(codes) == 0 {
+		return nil
+	}
+	languageCode := codes[:1]
+	texts := strings.Split(surface, morphemeSeparator)
+	lemmas := strings.Split(lemma, morphemeSeparator)
+	parts := strings.Split(codes[1:], morphemeSeparator)
+	remaining := 0
+	for i := range parts {
+		if hasLemma(languageCode, parts, i) {
+			remaining++
+		}
+	}
+	var morphemes []models.WlcMorpheme
+	lemmaIndex := 0
+	for i, part := range parts {
+		var morpheme models.WlcMorpheme
+		morpheme.Text = segment(texts, i)
+		morpheme.Code = languageCode + part
+		if hasLemma(languageCode, parts, i) {
+			morpheme.Lemma = segment(lemmas, lemmaIndex)
+			lemmaIndex++
+			remaining--
+			if _, ok := lemmaPrefixes[morpheme.Lemma]; ok {
+				morpheme.Prefix = morpheme.Lemma
+				//+ a marker and a Strong's number both belong to the last
+				//+ morpheme with a lemma, as in מִ/כֶּם, m/4480 a, HR/Sp2mp
+				next := segment(lemmas, lemmaIndex)
+				if _, isPrefix := lemmaPrefixes[next]; remaining == 0 && len(next) > 0 && !isPrefix {
+					morpheme.Lemma = next
+					morpheme.Strongs = parseStrongs(next)
+					lemmaIndex++
+				}
+			} else {
+				morpheme.Strongs = parseStrongs(morpheme.Lemma)
+			}
+		}
+		if i < len(morphologyArray) {
+			morpheme.Morphology = morphologyArray[i]
+		}
+		morphemes = append(morphemes, morpheme)
+	}
+	return morphemes
+}
+
+// ExpandMorphemes turns each word into one row per morpheme. Each row keeps
+// the word's verse and appends the morpheme position to its sequence ID.
+func ExpandMorphemes(words []models.WlcWord) []models.WlcWord {
+	var rows []models.WlcWord
+	for _, word := range words {
+		for i, morpheme := range word.Morphemes {
+			var prefixes []string
+			var strongsID string
+			if len(morpheme.Prefix) > 0 {
+				prefixes = []string{morpheme.Prefix}
+			}
+			if morpheme.Strongs != nil {
+				strongsID = morpheme.Strongs.String()
+			}
+			rows = append(rows, models.WlcWord{
+				Codes:                 morpheme.Code,
+				Language:              word.Language,
+				Lemma:                 morpheme.Text,
+				Consonantal:           hebrew.Consonantal(morpheme.Text),
+				Pointed:               hebrew.Pointed(morpheme.Text),
+				Normalized:            hebrew.Normalized(morpheme.Text),
+				Transliteration:       morpheme.Transliteration,
+				SimpleTransliteration: morpheme.SimpleTransliteration,
+				Accent:                word.Accent,
+				Depth:                 word.Depth,
+				Half:                  word.Half,
+				Segment:               word.Segment,
+				ID:                    morpheme.Lemma,
+				Prefixes:              prefixes,
+				Strongs:               morpheme.Strongs,
+				StrongsID:             strongsID,
+				Morphology:            []models.WlcMorphology{morpheme.Morphology},
+				Parsing:               morpheme.Parsing,
+				Morpheme:              i + 1,
+				SequenceID:            word.SequenceID*10 + int64(i+1),
+				Verse:                 word.Verse,
+				Reference:             word.Reference,
+				EnglishVerse:          word.EnglishVerse,
+				EnglishReference:      word.EnglishReference,
+				MorphologyString:      morpheme.Morphology.String(),
+			})
+		}
+	}
+	return rows
+}
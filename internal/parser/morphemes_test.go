@@ -0,0 +1,37 @@
+package parser
+
+import "testing"
+
+func TestSplitMorphemesPrefixMarkerWithStrongs(t *testing.T) {
+	morphemes := splitMorphemes("מִ/כֶּם", "m/4480 a", "HR/Sp2mp", nil)
+	if len(morphemes) != 2 {
+		t.Fatalf("got %d morphemes, want 2", len(morphemes))
+	}
+	preposition := morphemes[0]
+	if preposition.Prefix != "m" {
+		t.Errorf("prefix = %q, want m", preposition.Prefix)
+	}
+	if preposition.Strongs == nil || preposition.Strongs.String() != "H4480a" {
+		t.Errorf("strongs = %v, want H4480a", preposition.Strongs)
+	}
+	if preposition.Lemma != "4480 a" {
+		t.Errorf("lemma = %q, want 4480 a", preposition.Lemma)
+	}
+	suffix := morphemes[1]
+	if suffix.Lemma != "" || suffix.Strongs != nil {
+		t.Errorf("suffix got lemma %q and strongs %v", suffix.Lemma, suffix.Strongs)
+	}
+}
+
+func TestSplitMorphemesPrefixBeforeWord(t *testing.T) {
+	morphemes := splitMorphemes("בְּ/רֵאשִׁית", "b/7225", "HR/Ncfsa", nil)
+	if len(morphemes) != 2 {
+		t.Fatalf("got %d morphemes, want 2", len(morphemes))
+	}
+	if morphemes[0].Prefix != "b" || morphemes[0].Strongs != nil {
+		t.Errorf("preposition = %+v, want prefix b without strongs", morphemes[0])
+	}
+	if morphemes[1].Strongs == nil || morphemes[1].Strongs.String() != "H7225" {
+		t.Errorf("noun strongs = %v, want H7225", morphemes[1].Strongs)
+	}
+}
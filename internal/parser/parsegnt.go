@@ -2,56 +2,32 @@ package parser
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/davidbetz/morph/internal/canon"
+	"github.com/davidbetz/morph/internal/describe"
+	"github.com/davidbetz/morph/internal/greek"
 	"github.com/davidbetz/morph/internal/models"
 	"github.com/davidbetz/morph/internal/util"
 )
 
-const (
-	bookOffset = 60
-)
-
-func (t *Gnt) getPartName(part string) string {
-	switch part {
-	case "A-":
-		return "adjective"
-	case "C-":
-		return "conjunction"
-	case "D-":
-		return "adverb"
-	case "I-":
-		return "interjection"
-	case "N-":
-		return "noun"
-	case "P-":
-		return "preposition"
-	case "RA":
-		return "definite article"
-	case "RD":
-		return "demonstrative pronoun"
-	case "RI":
-		return "interrogative/indefinite pronoun"
-	case "RP":
-		return "personal pronoun"
-	case "RR":
-		return "relative pronoun"
-	case "V-":
-		return "verb"
-	case "X-":
-		return "particle"
-	default:
-		util.Errorf("invalid part " + part)
-		return ""
+func (t *Gnt) getPartName(part string) (string, error) {
+	name, ok := t.partLookup[part]
+	if !ok {
+		return "", codeErrorf(part, "invalid part")
 	}
+	return name, nil
 }
 
-func (t *Gnt) getMorphology(part string, code string) models.GntMorphology {
+func (t *Gnt) getMorphology(part string, code string) (models.GntMorphology, error) {
+	partName, err := t.getPartName(part)
+	if err != nil {
+		return models.GntMorphology{}, err
+	}
 	var person string
 	var tense string
 	var voice string
@@ -89,7 +65,7 @@ func (t *Gnt) getMorphology(part string, code string) models.GntMorphology {
 		}
 	}
 	return models.GntMorphology{
-		Part:   t.getPartName(part),
+		Part:   partName,
 		Person: person,
 		Tense:  tense,
 		Voice:  voice,
@@ -98,11 +74,17 @@ func (t *Gnt) getMorphology(part string, code string) models.GntMorphology {
 		Number: number,
 		Gender: gender,
 		Degree: degree,
-	}
+	}, nil
 }
 
 // Gnt represents the GNT parser
 type Gnt struct {
+	problems
+	pool
+	selection    canon.Selection
+	parsing      *describe.Formatter
+	skip         func(name string) bool
+	partLookup   map[string]string
 	personLookup map[string]string
 	tenseLookup  map[string]string
 	voiceLookup  map[string]string
@@ -111,47 +93,24 @@ type Gnt struct {
 	numberLookup map[string]string
 	genderLookup map[string]string
 	degreeLookup map[string]string
-	bookOrder    map[string]int
-	bookNames    map[int]string
 }
 
 func (t *Gnt) setupTables() {
-	t.bookOrder = make(map[string]int, 39)
-	t.bookOrder["Matthew"] = 1
-	t.bookOrder["Mark"] = 2
-	t.bookOrder["Luke"] = 3
-	t.bookOrder["John"] = 4
-	t.bookOrder["Acts"] = 5
-	t.bookOrder["Romans"] = 6
-	t.bookOrder["1 Corinthians"] = 7
-	t.bookOrder["2 Corinthians"] = 8
-	t.bookOrder["Galatians"] = 9
-	t.bookOrder["Ephesians"] = 10
-	t.bookOrder["Philippians"] = 11
-	t.bookOrder["Colossians"] = 12
-	t.bookOrder["1 Thessalonians"] = 13
-	t.bookOrder["2 Thessalonians"] = 14
-	t.bookOrder["1 Timothy"] = 15
-	t.bookOrder["2 Timothy"] = 16
-	t.bookOrder["Titus"] = 17
-	t.bookOrder["Philemon"] = 18
-	t.bookOrder["Hebrews"] = 19
-	t.bookOrder["James"] = 20
-	t.bookOrder["1 Peter"] = 21
-	t.bookOrder["2 Peter"] = 22
-	t.bookOrder["1 John"] = 23
-	t.bookOrder["2 John"] = 24
-	t.bookOrder["3 John"] = 25
-	t.bookOrder["Jude"] = 26
-	t.bookOrder["Revelation"] = 27
-
-	bookOffset := 60
-	t.bookNames = make(map[int]string, 27)
-	for k, v := range t.bookOrder {
-		v += bookOffset
-		t.bookNames[v] = k
+	t.partLookup = map[string]string{
+		"A-": "adjective",
+		"C-": "conjunction",
+		"D-": "adverb",
+		"I-": "interjection",
+		"N-": "noun",
+		"P-": "preposition",
+		"RA": "definite article",
+		"RD": "demonstrative pronoun",
+		"RI": "interrogative/indefinite pronoun",
+		"RP": "personal pronoun",
+		"RR": "relative pronoun",
+		"V-": "verb",
+		"X-": "particle",
 	}
-
 	t.personLookup = map[string]string{
 		"1": "first",
 		"2": "second",
@@ -199,67 +158,140 @@ func (t *Gnt) setupTables() {
 	}
 }
 
-func (t *Gnt) createAbsoluteID(verse string, id int) int64 {
-	newID, err := strconv.ParseInt(verse+strconv.Itoa(id), 10, 32)
-	if err != nil {
-		util.Errorf(err.Error())
-	}
-	return newID
-}
-
-func (t *Gnt) readFile(filename string) ([][]string, error) {
+// readLines reads a MorphGNT book file one line at a time and passes each
+// line's fields to fn, so a book is never held as a whole [][]string
+func (t *Gnt) readLines(filename string, fn func(line int, parts []string) error) error {
 	if filepath.Ext(filename) != ".txt" {
-		return nil, errors.New("Skip")
+		return errSkip
 	}
 	util.Debug(fmt.Sprintf("PARSING: %v\n", filename))
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error closing file: %v\n", err)
 		}
 	}()
-	var lines [][]string
 	scanner := bufio.NewScanner(file)
+	line := 0
 	for scanner.Scan() {
-		lines = append(lines, strings.Split(scanner.Text(), " "))
+		line++
+		err = fn(line, strings.Split(scanner.Text(), " "))
+		if err != nil {
+			return err
+		}
 	}
-	return lines, nil
+	if err := scanner.Err(); err != nil {
+		return &ParseError{File: filename, Err: fmt.Errorf("line %d: %s", line+1, err.Error())}
+	}
+	return nil
 }
 
 func (t *Gnt) ParseFileContent(filename string) ([]models.GntWord, error) {
 	words := []models.GntWord{}
-	lines, err := t.readFile(filename)
-	if err != nil {
-		return nil, err
-	}
 	var id int
+	var sentence, clause int64
+	var endsSentence, endsClause bool
 	originalVerse := ""
-	for _, parts := range lines {
-		if originalVerse != parts[0] {
+	err := t.readLines(filename, func(line int, parts []string) error {
+		if _, err := strconv.Atoi(parts[0]); err == nil && len(parts[0]) == 6 && originalVerse != parts[0] {
 			originalVerse = parts[0]
 			id = 1
 		}
-		bookNumber, _ := strconv.ParseInt(originalVerse[0:2], 10, 32)
-		verse := strconv.Itoa(int(bookNumber)+39) + originalVerse[2:4] + originalVerse[4:6]
-		uniqueID := t.createAbsoluteID(verse, id)
+		var bookNumber, chapter, verse int
+		if len(originalVerse) == 6 {
+			bookNumber, _ = strconv.Atoi(originalVerse[0:2])
+			chapter, _ = strconv.Atoi(originalVerse[2:4])
+			verse, _ = strconv.Atoi(originalVerse[4:6])
+		}
+		reference := canon.NewReference(bookNumber+canon.OldTestamentBooks, chapter, verse, id)
+		if len(parts) != 7 || originalVerse != parts[0] {
+			//+ a malformed line still takes a word's place in its verse, so
+			//+ the words after it keep their IDs
+			bookName := ""
+			if bookNumber > 0 {
+				book, _ := canon.BookByNumber(reference.Book)
+				bookName = book.Name
+			}
+			err := t.report(locate(fmt.Errorf("line %d: expected 7 fields starting with BBCCVV", line), filename, bookName, chapter, verse, id))
+			id++
+			return err
+		}
+		//+ segments are tracked over every line so their IDs don't depend
+		//+ on the selection
+		leading, _, trailing := greek.SplitPunctuation(parts[3])
+		if sentence == 0 || endsSentence {
+			sentence = reference.WordID()
+		}
+		if clause == 0 || endsClause {
+			clause = reference.WordID()
+		}
+		endsSentence, endsClause = greek.EndsSentence(trailing), greek.EndsClause(trailing)
+		if !t.selection.Includes(reference) {
+			id++
+			return nil
+		}
+		morphology, err := t.getMorphology(parts[1], parts[2])
+		if err == nil && t.strict {
+			err = t.validateWord(parts, morphology)
+		}
+		if err != nil {
+			book, _ := canon.BookByNumber(reference.Book)
+			err = t.report(locate(err, filename, book.Name, chapter, verse, id))
+			id++
+			return err
+		}
 		words = append(words, models.GntWord{
-			ID:         uniqueID,
-			Verse:      verse,
+			ID:         reference.WordID(),
+			Verse:      reference.VerseID(),
+			Reference:  reference,
 			Codes:      parts[2],
-			Morphology: t.getMorphology(parts[1], parts[2]),
+			Morphology: morphology,
 			Text:       parts[3],
 			Word:       parts[4],
 			Normalized: parts[5],
 			Lemma:      parts[6],
+			Leading:    leading,
+			Trailing:   trailing,
+			Sentence:   sentence,
+			Clause:     clause,
+
+			Folded:               greek.Fold(parts[4]),
+			LemmaFolded:          greek.Fold(parts[6]),
+			BetaCode:             greek.BetaCode(parts[4]),
+			LemmaBetaCode:        greek.BetaCode(parts[6]),
+			Transliteration:      greek.Transliterate(parts[4]),
+			LemmaTransliteration: greek.Transliterate(parts[6]),
 		})
+		if t.parsing != nil {
+			words[len(words)-1].Parsing = t.parsing.Gnt(morphology)
+		}
 		id++
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return words, nil
 }
 
+// Parsing adds a formatted parsing to every word
+func (t *Gnt) Parsing(formatter *describe.Formatter) {
+	t.parsing = formatter
+}
+
+// SkipBooks has Books pass over the books skip returns true for
+func (t *Gnt) SkipBooks(skip func(name string) bool) {
+	t.skip = skip
+}
+
+// Select restricts parsing to the selected books and verses
+func (t *Gnt) Select(selection canon.Selection) {
+	t.selection = selection
+}
+
 // CreateGnt creates a Gnt parser
 func CreateGnt() *Gnt {
 	gnt := &Gnt{}
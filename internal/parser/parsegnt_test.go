@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGntMalformedLineKeepsWordIDs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "61-Mt-morphgnt.txt")
+	lines := []string{
+		"010101 N- ----NSF- Βίβλος Βίβλος βίβλος βίβλος",
+		"010101 N- ----GSF- γενέσεως",
+		"010101 N- ----GSM- Ἰησοῦ Ἰησοῦ Ἰησοῦ Ἰησοῦς",
+		"010102 N- ----NSM- Ἀβραὰμ Ἀβραὰμ Ἀβραάμ Ἀβραάμ",
+	}
+	err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gnt := CreateGnt()
+	gnt.ContinueOnError(true)
+	words, err := gnt.ParseFileContent(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, word := range words {
+		ids = append(ids, word.ID)
+	}
+	if len(ids) != 3 || ids[0] != 40001001001 || ids[1] != 40001001003 || ids[2] != 40001002001 {
+		t.Errorf("got IDs %v, want 40001001001, 40001001003 and 40001002001", ids)
+	}
+	if words[1].Sentence != 40001001001 {
+		t.Errorf("sentence = %d, want 40001001001", words[1].Sentence)
+	}
+	var errs ParseErrors
+	if !errors.As(gnt.result(), &errs) || len(errs) != 1 {
+		t.Fatalf("got %v, want one problem", gnt.result())
+	}
+	problem := errs[0]
+	if problem.Book != "Matthew" || problem.Chapter != 1 || problem.Verse != 1 || problem.Word != 2 {
+		t.Errorf("problem at %s %d:%d.%d, want Matthew 1:1.2", problem.Book, problem.Chapter, problem.Verse, problem.Word)
+	}
+}
@@ -1,16 +1,18 @@
 package parser
 
 import (
+	"bufio"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/davidbetz/morph/internal/canon"
+	"github.com/davidbetz/morph/internal/describe"
+	"github.com/davidbetz/morph/internal/hebrew"
 	"github.com/davidbetz/morph/internal/models"
 	"github.com/davidbetz/morph/internal/util"
 )
@@ -32,9 +34,13 @@ func shiftString(text string) string {
 
 // Wlc represents the WLC parser
 type Wlc struct {
+	problems
+	pool
 	style                      string
-	bookOrder                  map[string]int
-	filenames                  map[string]string
+	selection                  canon.Selection
+	parsing                    *describe.Formatter
+	transliterate              bool
+	skip                       func(name string) bool
 	partOfSpeechLookup         map[string]string
 	hebrewStemLookup           map[string]string
 	aramaicVerbLookup          map[string]string
@@ -74,49 +80,6 @@ func (t *Wlc) setupTables() {
 	t.languageVerbLookup = make(map[string]map[string]string, 2)
 	t.notUsedLookup = make(map[string]string, 1)
 
-	t.bookOrder = make(map[string]int, 39)
-	t.filenames = make(map[string]string, 39)
-
-	t.bookOrder["Genesis"] = 1
-	t.bookOrder["Exodus"] = 2
-	t.bookOrder["Leviticus"] = 3
-	t.bookOrder["Numbers"] = 4
-	t.bookOrder["Deuteronomy"] = 5
-	t.bookOrder["Joshua"] = 6
-	t.bookOrder["Judges"] = 7
-	t.bookOrder["Ruth"] = 8
-	t.bookOrder["I Samuel"] = 9
-	t.bookOrder["II Samuel"] = 10
-	t.bookOrder["I Kings"] = 11
-	t.bookOrder["II Kings"] = 12
-	t.bookOrder["I Chronicles"] = 13
-	t.bookOrder["II Chronicles"] = 14
-	t.bookOrder["Ezra"] = 15
-	t.bookOrder["Nehemiah"] = 16
-	t.bookOrder["Esther"] = 17
-	t.bookOrder["Job"] = 18
-	t.bookOrder["Psalms"] = 19
-	t.bookOrder["Proverbs"] = 20
-	t.bookOrder["Ecclesiastes"] = 21
-	t.bookOrder["Song of Solomon"] = 22
-	t.bookOrder["Isaiah"] = 23
-	t.bookOrder["Jeremiah"] = 24
-	t.bookOrder["Lamentations"] = 25
-	t.bookOrder["Ezekiel"] = 26
-	t.bookOrder["Daniel"] = 27
-	t.bookOrder["Hosea"] = 28
-	t.bookOrder["Joel"] = 29
-	t.bookOrder["Amos"] = 30
-	t.bookOrder["Obadiah"] = 31
-	t.bookOrder["Jonah"] = 32
-	t.bookOrder["Micah"] = 33
-	t.bookOrder["Nahum"] = 34
-	t.bookOrder["Habakkuk"] = 35
-	t.bookOrder["Zephaniah"] = 36
-	t.bookOrder["Haggai"] = 37
-	t.bookOrder["Zechariah"] = 38
-	t.bookOrder["Malachi"] = 39
-
 	t.partOfSpeechLookup["A"] = "adjective"
 	t.partOfSpeechLookup["C"] = "conjunction"
 	t.partOfSpeechLookup["D"] = "adverb"
@@ -250,9 +213,6 @@ func (t *Wlc) setupTables() {
 	t.languageVerbLookup["A"] = t.aramaicVerbLookup
 
 	t.notUsedLookup["x"] = "-"
-	for k := range t.bookOrder {
-		t.filenames[strings.ToLower(strings.Replace(k, " ", "", -1))] = k
-	}
 	//+ POS to *node
 	t.trees = make(map[string]*node, 7)
 	gnsBranch := &node{
@@ -301,30 +261,12 @@ func (t *Wlc) setupTables() {
 		Lookup:  t.pronounLookup,
 		TopName: "pronoun",
 		Name:    "Type",
-		Decider: func(next string) bool {
-			return next == "x"
-		},
-		Next: pgnBranch,
-		Alternate: &node{
-			Lookup: t.notUsedLookup,
-			Name:   "-",
-			Next: &node{
-				Lookup: t.hebrewGenderLookup,
-				Name:   "Gender",
-				Next: &node{
-					Lookup: t.hebrewNumberLookup,
-					Name:   "Number",
-					Next: &node{
-						Lookup: t.stateLookup,
-						Name:   "State",
-					},
-				},
-			},
-		},
+		Next:    pgnBranch,
 	}
 	t.trees["R"] = &node{
-		Name:   "preposition",
-		Lookup: t.prepositionLookup,
+		Lookup:  t.prepositionLookup,
+		TopName: "preposition",
+		Name:    "Type",
 	}
 	t.trees["S"] = &node{
 		Lookup:  t.suffixLookup,
@@ -369,7 +311,7 @@ func (t *Wlc) setupTables() {
 	}
 	t.trees["VA"] = &node{
 		Lookup:  t.aramaicVerbLookup,
-		TopName: "Verb",
+		TopName: "verb",
 		Name:    "Stem",
 		Next: &node{
 			Lookup:  t.verbConjugationTypesLookup,
@@ -396,8 +338,31 @@ func (t *Wlc) setupTables() {
 	}
 }
 
-func (t *Wlc) parseMorphology(morph string) (string, []map[string]string) {
+// setField stores value in the morphology field a tree node is named after
+func setField(m *models.WlcMorphology, name string, value string) {
+	switch name {
+	case "Type":
+		m.Type = value
+	case "Stem":
+		m.Stem = value
+	case "Conjugation":
+		m.Conjugation = value
+	case "Person":
+		m.Person = value
+	case "Gender":
+		m.Gender = value
+	case "Number":
+		m.Number = value
+	case "State":
+		m.State = value
+	}
+}
+
+func (t *Wlc) parseMorphology(morph string) (string, []models.WlcMorphology, error) {
 	original := morph
+	if len(morph) == 0 {
+		return "", nil, codeErrorf(morph, "empty morphology")
+	}
 	languageCode := string(morph[0])
 	morph = shiftString(morph)
 	var language string
@@ -408,13 +373,18 @@ func (t *Wlc) parseMorphology(morph string) (string, []map[string]string) {
 	case "A":
 		language = "Aramaic"
 		break
+	default:
+		return "", nil, codeErrorf(original, "unknown language %q", languageCode)
 	}
 	util.Debug(fmt.Sprintf("STARTING NEXT WORD %s %s\n", original, language))
-	var morphologyArray []map[string]string
+	var morphologyArray []models.WlcMorphology
 	parts := strings.Split(morph, "/")
 	for _, part := range parts {
 		// original := part
-		m := make(map[string]string)
+		m := models.WlcMorphology{Language: language}
+		if len(part) == 0 {
+			return "", nil, codeErrorf(original, "empty morpheme")
+		}
 		partOfSpeechCode := string(part[0])
 		part = shiftString(part)
 		var tree *node
@@ -425,110 +395,265 @@ func (t *Wlc) parseMorphology(morph string) (string, []map[string]string) {
 			tree = t.trees[partOfSpeechCode]
 			util.Debug(fmt.Sprintf("TREE: %s %v\n", partOfSpeechCode, tree))
 		}
+		if tree == nil {
+			return "", nil, codeErrorf(original, "unknown part of speech %q", partOfSpeechCode)
+		}
 		topName := tree.TopName
 		if len(topName) == 0 {
 			topName = tree.Name
 		}
-		m["Part"] = topName
+		m.Part = topName
 		util.Debug(fmt.Sprintf("\tSTARTING NEXT PART, %s %s\n", original, tree.Name))
 		for _, l := range part {
 			letter := string(l)
-			util.Debug(fmt.Sprintf("\t\tSTARTING NEXT LETTER, %s %q\n", letter, tree))
-			if tree.Name != "-" {
-				m[tree.Name] = tree.Lookup[letter]
+			util.Debug(fmt.Sprintf("\t\tSTARTING NEXT LETTER, %s %v\n", letter, tree))
+			if tree == nil {
+				return "", nil, codeErrorf(original, "unexpected %q after %s", letter, topName)
 			}
-			if tree != nil {
-				if d := tree.Decider; d != nil {
-					if d(letter) {
-						util.Debug(fmt.Sprintf("DECIDER CALLED TRUE %s\n", letter))
-						tree = tree.Next
-					} else {
-						util.Debug(fmt.Sprintf("DECIDER CALLED FALSE %s\n", letter))
-						tree = tree.Alternate
-					}
-				} else {
+			//+ x marks a slot that doesn't apply, e.g. the person of a demonstrative
+			if _, ok := t.notUsedLookup[letter]; !ok {
+				value, ok := tree.Lookup[letter]
+				if !ok && t.strict {
+					return "", nil, codeErrorf(original, "unknown %s %q", strings.ToLower(tree.Name), letter)
+				}
+				setField(&m, tree.Name, value)
+			}
+			if d := tree.Decider; d != nil {
+				if d(letter) {
+					util.Debug(fmt.Sprintf("DECIDER CALLED TRUE %s\n", letter))
 					tree = tree.Next
+				} else {
+					util.Debug(fmt.Sprintf("DECIDER CALLED FALSE %s\n", letter))
+					tree = tree.Alternate
 				}
+			} else {
+				tree = tree.Next
 			}
 		}
 		morphologyArray = append(morphologyArray, m)
 	}
-	return language, morphologyArray
+	return language, morphologyArray, nil
 }
 
-func (t *Wlc) Parse(word []string, verseID string, sequence int64) models.WlcWord {
+func (t *Wlc) Parse(word []string, reference models.Reference) (models.WlcWord, error) {
+	if len(word) != 3 {
+		return models.WlcWord{}, fmt.Errorf("expected text, lemma and morphology but found %d fields", len(word))
+	}
 	lemma := word[0]
 	id := word[1]
 	morph := word[2]
-	language, morphologyArray := t.parseMorphology(morph)
+	language, morphologyArray, err := t.parseMorphology(morph)
+	if err != nil {
+		return models.WlcWord{}, err
+	}
+	if t.strict {
+		err = t.validateWord(lemma, id, morph, morphologyArray)
+		if err != nil {
+			return models.WlcWord{}, err
+		}
+	}
 	var outer []string
 	for _, morph := range morphologyArray {
-		var inner []string
-		for k, v := range morph {
-			inner = append(inner, k+"="+v)
-		}
-		outer = append(outer, strings.Join(inner, ","))
+		outer = append(outer, morph.String())
+	}
+	prefixes, strongs := parseLemma(id)
+	var strongsID string
+	if strongs != nil {
+		strongsID = strongs.String()
 	}
-	return models.WlcWord{
+	parsed := models.WlcWord{
 		Codes:            morph,
 		Language:         language,
 		Morphology:       morphologyArray,
+		Morphemes:        splitMorphemes(lemma, id, morph, morphologyArray),
 		MorphologyString: strings.Join(outer, "|"),
 		Lemma:            lemma,
+		Consonantal:      hebrew.Consonantal(lemma),
+		Pointed:          hebrew.Pointed(lemma),
+		Normalized:       hebrew.Normalized(lemma),
 		ID:               id,
-		Verse:            verseID,
-		SequenceID:       sequence,
+		Prefixes:         prefixes,
+		Strongs:          strongs,
+		StrongsID:        strongsID,
+		Verse:            reference.VerseID(),
+		Reference:        reference,
+		SequenceID:       reference.WordID(),
 	}
+	if t.transliterate {
+		parsed.Transliteration = hebrew.Transliterate(lemma, hebrew.Academic)
+		parsed.SimpleTransliteration = hebrew.Transliterate(lemma, hebrew.Simple)
+		academic := hebrew.TransliterateMorphemes(lemma, hebrew.Academic)
+		simple := hebrew.TransliterateMorphemes(lemma, hebrew.Simple)
+		for i := range parsed.Morphemes {
+			parsed.Morphemes[i].Transliteration = segment(academic, i)
+			parsed.Morphemes[i].SimpleTransliteration = segment(simple, i)
+		}
+	}
+	if t.parsing != nil {
+		parsed.Parsing = t.parsing.Wlc(morphologyArray)
+		for i := range parsed.Morphemes {
+			parsed.Morphemes[i].Parsing = t.parsing.Wlc(morphologyArray[i : i+1])
+		}
+	}
+	return parsed, nil
 }
 
-func (t *Wlc) ReadFile(filename string) ([]byte, error) {
+// openFile opens a WLC book file, which is JSON
+func (t *Wlc) openFile(filename string) (*os.File, error) {
 	if filepath.Ext(filename) != ".json" {
 		return nil, errors.New("not json")
 	}
-	file, err := os.Open(filename)
+	return os.Open(filename)
+}
+
+// readVerses decodes a WLC book file one verse at a time and passes each
+// to fn, so a book is never held as a whole [][][][]string. A syntax error
+// is located at the verse being decoded.
+func (t *Wlc) readVerses(filename string, bookName string, fn func(chapter int, verse int, words [][]string) error) error {
+	file, err := t.openFile(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error closing file: %v\n", err)
 		}
 	}()
-	return io.ReadAll(file)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	chapter, verse := 0, 0
+	fail := func(err error) error {
+		return locate(err, filename, bookName, chapter, verse, 0)
+	}
+	if err := expectDelim(decoder, '['); err != nil {
+		return fail(err)
+	}
+	for decoder.More() {
+		chapter, verse = chapter+1, 0
+		if err := expectDelim(decoder, '['); err != nil {
+			return fail(err)
+		}
+		for decoder.More() {
+			verse++
+			var words [][]string
+			if err := decoder.Decode(&words); err != nil {
+				return fail(err)
+			}
+			if err := fn(chapter, verse, words); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return fail(err)
+		}
+	}
+	chapter, verse = 0, 0
+	if err := expectDelim(decoder, ']'); err != nil {
+		return fail(err)
+	}
+	return nil
 }
 
-func (t *Wlc) ParseFileContent(bookName string, filename string) ([]models.WlcWord, error) {
-	buffer, err := t.ReadFile(filename)
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var obj [][][][]string
-	err = json.Unmarshal(buffer, &obj)
-	if err != nil {
-		return nil, err
+	if token != delim {
+		return fmt.Errorf("expected %q, found %v", delim, token)
+	}
+	return nil
+}
+
+func (t *Wlc) ParseFileContent(bookName string, filename string) ([]models.WlcWord, error) {
+	return t.parseFile(bookName, filename, t.selection)
+}
+
+func (t *Wlc) parseFile(bookName string, filename string, selection canon.Selection) ([]models.WlcWord, error) {
+	book, ok := canon.BookByName(bookName)
+	if !ok {
+		return nil, fmt.Errorf("unknown book %s", bookName)
 	}
-	bookID := t.bookOrder[bookName]
-	wordid := 1
 	var words []models.WlcWord
-	for ci, chapter := range obj {
-		for vi, verse := range chapter {
-			for _, word := range verse {
-				verseID := fmt.Sprintf("%02d%03d%03d", bookID, ci+1, vi+1)
-				sequenceID := fmt.Sprintf("%02d%03d%03d%03d", bookID, ci+1, vi+1, wordid)
-				sequence, err := strconv.ParseInt(sequenceID, 10, 64)
+	err := t.readVerses(filename, book.Name, func(chapter int, verse int, verseWords [][]string) error {
+		if len(verseWords) == 0 && t.strict {
+			err := t.report(&ParseError{File: filename, Book: book.Name, Chapter: chapter, Verse: verse, Err: errors.New("empty verse")})
+			if err != nil {
+				return err
+			}
+		}
+		divisions := divide(book.Number, chapter, verse, verseWords)
+		for wi, word := range verseWords {
+			reference := canon.NewReference(book.Number, chapter, verse, wi+1)
+			if !selection.Includes(reference) {
+				continue
+			}
+			parsed, err := t.Parse(word, reference)
+			if err != nil {
+				err = t.report(locate(err, filename, book.Name, chapter, verse, wi+1))
 				if err != nil {
-					util.Errorf(err.Error())
+					return err
 				}
-				word := t.Parse(word, verseID, sequence)
-				words = append(words, word)
-				wordid++
+				continue
 			}
+			division := divisions[wi]
+			parsed.Accent, parsed.Depth = division.Accent, division.Depth
+			parsed.Half, parsed.Segment = division.Half, division.Segment
+			words = append(words, parsed)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return words, nil
 }
 
-// CreateWlc creates a Gnt parser
+// poetic reports whether a verse is accented in the poetic system: Psalms,
+// Proverbs and Job 3:2-42:6
+func poetic(book int, chapter int, verse int) bool {
+	switch book {
+	case 19, 20:
+		return true
+	case 18:
+		return (chapter > 3 || chapter == 3 && verse > 1) && (chapter < 42 || chapter == 42 && verse <= 6)
+	}
+	return false
+}
+
+// divide places every word of a verse in its division by the accents, so
+// the result doesn't depend on which words are selected
+func divide(book int, chapter int, verse int, words [][]string) []hebrew.Division {
+	surfaces := make([]string, len(words))
+	for i, word := range words {
+		if len(word) > 0 {
+			surfaces[i] = word[0]
+		}
+	}
+	return hebrew.Divide(surfaces, poetic(book, chapter, verse))
+}
+
+// Parsing adds a formatted parsing to every word and morpheme
+func (t *Wlc) Parsing(formatter *describe.Formatter) {
+	t.parsing = formatter
+}
+
+// Transliterate adds SBL academic and simple transliterations to every
+// word and morpheme
+func (t *Wlc) Transliterate(transliterate bool) {
+	t.transliterate = transliterate
+}
+
+// SkipBooks has Books pass over the books skip returns true for
+func (t *Wlc) SkipBooks(skip func(name string) bool) {
+	t.skip = skip
+}
+
+// Select restricts parsing to the selected books and verses
+func (t *Wlc) Select(selection canon.Selection) {
+	t.selection = selection
+}
+
+// CreateWlc creates a Wlc parser
 func CreateWlc(style string) *Wlc {
 	wlc := &Wlc{style: style}
 	wlc.setupTables()
@@ -0,0 +1,71 @@
+package parser
+
+import "context"
+
+// pool parses several books at once while passing them on in order
+type pool struct {
+	workers int
+}
+
+// Concurrency parses up to n books at once. Books are still passed to
+// Books' fn one at a time, in order; a book parsed ahead waits its turn.
+func (p *pool) Concurrency(n int) {
+	p.workers = n
+}
+
+type parsed struct {
+	book interface{}
+	err  error
+}
+
+// each parses count books with parse, up to workers at a time, and passes
+// them to deliver in order. parse returns a nil book for one to skip. No
+// more than workers books are held at once, counting the one deliver has.
+func (p *pool) each(ctx context.Context, count int, parse func(i int) (interface{}, error), deliver func(book interface{}) error) error {
+	workers := p.workers
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]chan parsed, count)
+	for i := range results {
+		results[i] = make(chan parsed, 1)
+	}
+	slots := make(chan struct{}, workers)
+	go func() {
+		for i := 0; i < count; i++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int) {
+				book, err := parse(i)
+				results[i] <- parsed{book, err}
+			}(i)
+		}
+	}()
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var result parsed
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if result.err != nil {
+			return result.err
+		}
+		if result.book != nil {
+			err := deliver(result.book)
+			if err != nil {
+				return err
+			}
+		}
+		<-slots
+	}
+	return nil
+}
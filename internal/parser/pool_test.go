@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoolOrder(t *testing.T) {
+	tests := []struct {
+		workers int
+		count   int
+	}{
+		{0, 5},
+		{1, 5},
+		{3, 10},
+		{8, 4},
+		{4, 0},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d workers, %d books", test.workers, test.count), func(t *testing.T) {
+			p := &pool{}
+			p.Concurrency(test.workers)
+			limit := test.workers
+			if limit < 1 {
+				limit = 1
+			}
+			var mu sync.Mutex
+			held, most := 0, 0
+			var delivered []int
+			err := p.each(context.Background(), test.count, func(i int) (interface{}, error) {
+				mu.Lock()
+				held++
+				if held > most {
+					most = held
+				}
+				mu.Unlock()
+				//+ later books finish first
+				time.Sleep(time.Duration(test.count-i) * time.Millisecond)
+				if i%3 == 2 {
+					mu.Lock()
+					held--
+					mu.Unlock()
+					return nil, nil
+				}
+				return i, nil
+			}, func(book interface{}) error {
+				delivered = append(delivered, book.(int))
+				mu.Lock()
+				held--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []int
+			for i := 0; i < test.count; i++ {
+				if i%3 != 2 {
+					want = append(want, i)
+				}
+			}
+			if fmt.Sprint(delivered) != fmt.Sprint(want) {
+				t.Errorf("delivered %v, want %v", delivered, want)
+			}
+			if most > limit {
+				t.Errorf("held %d books at once, want at most %d", most, limit)
+			}
+		})
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	parseFailed := errors.New("parse failed")
+	deliverFailed := errors.New("deliver failed")
+	tests := []struct {
+		name      string
+		parse     error
+		deliver   error
+		cancel    bool
+		err       error
+		delivered string
+	}{
+		{"parse", parseFailed, nil, false, parseFailed, "[0 1 2]"},
+		{"deliver", nil, deliverFailed, false, deliverFailed, "[0 1 2 3]"},
+		{"cancelled", nil, nil, true, context.Canceled, "[0 1 2 3]"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			p := &pool{}
+			p.Concurrency(2)
+			var delivered []int
+			err := p.each(ctx, 10, func(i int) (interface{}, error) {
+				if i == 3 && test.parse != nil {
+					return nil, test.parse
+				}
+				return i, nil
+			}, func(book interface{}) error {
+				delivered = append(delivered, book.(int))
+				if book.(int) == 3 {
+					if test.cancel {
+						cancel()
+					}
+					return test.deliver
+				}
+				return nil
+			})
+			if !errors.Is(err, test.err) {
+				t.Errorf("got %v, want %v", err, test.err)
+			}
+			if got := fmt.Sprint(delivered); got != test.delivered {
+				t.Errorf("delivered %s, want %s", got, test.delivered)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// lemmaPrefixes are the prefix markers the WLC uses in place of a Strong's
+// number for attached particles
+var lemmaPrefixes = map[string]string{
+	"b": "preposition bet",
+	"c": "conjunction waw",
+	"d": "definite article",
+	"i": "interrogative he",
+	"k": "preposition kaf",
+	"l": "preposition lamed",
+	"m": "preposition mem",
+	"s": "relative shin",
+}
+
+// parseStrongs parses one lemma segment such as 1254, "1254 a" or 1254+
+func parseStrongs(segment string) *models.Strongs {
+	segment = strings.TrimSpace(segment)
+	compound := strings.HasSuffix(segment, "+")
+	segment = strings.TrimSuffix(segment, "+")
+	fields := strings.Fields(segment)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil
+	}
+	number, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil
+	}
+	strongs := &models.Strongs{
+		Number:   number,
+		Compound: compound,
+	}
+	if len(fields) == 2 {
+		strongs.Letter = fields[1]
+	}
+	return strongs
+}
+
+// parseLemma splits a WLC lemma such as c/d/776 into its prefix markers and
+// the Strong's reference of the main word
+func parseLemma(lemma string) ([]string, *models.Strongs) {
+	var prefixes []string
+	var strongs *models.Strongs
+	for _, segment := range strings.Split(lemma, morphemeSeparator) {
+		if _, ok := lemmaPrefixes[segment]; ok {
+			prefixes = append(prefixes, segment)
+			continue
+		}
+		if s := parseStrongs(segment); s != nil {
+			strongs = s
+		}
+	}
+	return prefixes, strongs
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// malformedText reports text that isn't valid UTF-8, carries replacement
+// characters from a bad decode, or has characters outside allowed
+func malformedText(text string, allowed func(r rune) bool) error {
+	if !utf8.ValidString(text) {
+		return errors.New("invalid UTF-8")
+	}
+	if len(text) == 0 {
+		return errors.New("empty text")
+	}
+	for _, r := range text {
+		if r == utf8.RuneError {
+			return errors.New("replacement character in text")
+		}
+		if !allowed(r) {
+			return fmt.Errorf("unexpected character %U %q", r, r)
+		}
+	}
+	return nil
+}
+
+func isHebrewText(r rune) bool {
+	return unicode.Is(unicode.Hebrew, r) || string(r) == morphemeSeparator
+}
+
+func isGreekText(r rune) bool {
+	return unicode.Is(unicode.Greek, r) || unicode.Is(unicode.Mn, r) || unicode.IsPunct(r)
+}
+
+// validateWord checks that a WLC word's text is well formed, that its
+// surface, lemma and morphology segments line up and that the decoded
+// morphology encodes back to the same code
+func (t *Wlc) validateWord(text string, lemma string, codes string, morphology []models.WlcMorphology) error {
+	if err := malformedText(text, isHebrewText); err != nil {
+		return codeErrorf(text, "%s", err.Error())
+	}
+	texts := strings.Split(text, morphemeSeparator)
+	parts := strings.Split(codes[1:], morphemeSeparator)
+	lemmas := strings.Split(lemma, morphemeSeparator)
+	if len(texts) != len(parts) {
+		return codeErrorf(text+" "+codes, "%d text segments but %d morphology segments", len(texts), len(parts))
+	}
+	lemmaParts := 0
+	for i := range parts {
+		if hasLemma(codes[:1], parts, i) {
+			lemmaParts++
+		}
+	}
+	//+ a preposition sometimes lists both its prefix letter and its
+	//+ Strong's number, e.g. m/4480 for one R morpheme
+	strongs := 0
+	for _, l := range lemmas {
+		if _, ok := lemmaPrefixes[l]; !ok {
+			strongs++
+		}
+	}
+	if len(lemmas) < lemmaParts || strongs > lemmaParts {
+		return codeErrorf(lemma+" "+codes, "%d lemma segments but %d morphology segments that take a lemma", len(lemmas), lemmaParts)
+	}
+	encoded, err := t.Encode(morphology)
+	if err != nil {
+		return codeErrorf(codes, "%s", err.Error())
+	}
+	if encoded != codes {
+		return codeErrorf(codes, "encodes back as %s", encoded)
+	}
+	return nil
+}
+
+type gntPosition struct {
+	name   string
+	values map[string]string
+	field  func(m *models.GntMorphology) *string
+}
+
+func (p gntPosition) get(m models.GntMorphology) string {
+	return *p.field(&m)
+}
+
+func (p gntPosition) set(m *models.GntMorphology, value string) {
+	*p.field(m) = value
+}
+
+// positionLookups lists the lookup for each letter of a GNT code in order
+func (t *Gnt) positionLookups() []gntPosition {
+	return []gntPosition{
+		{"person", t.personLookup, func(m *models.GntMorphology) *string { return &m.Person }},
+		{"tense", t.tenseLookup, func(m *models.GntMorphology) *string { return &m.Tense }},
+		{"voice", t.voiceLookup, func(m *models.GntMorphology) *string { return &m.Voice }},
+		{"mood", t.moodLookup, func(m *models.GntMorphology) *string { return &m.Mood }},
+		{"case", t.caseLookup, func(m *models.GntMorphology) *string { return &m.Case }},
+		{"number", t.numberLookup, func(m *models.GntMorphology) *string { return &m.Number }},
+		{"gender", t.genderLookup, func(m *models.GntMorphology) *string { return &m.Gender }},
+		{"degree", t.degreeLookup, func(m *models.GntMorphology) *string { return &m.Degree }},
+	}
+}
+
+// validateCode checks that every letter of a GNT code is defined for its
+// position
+func (t *Gnt) validateCode(code string) error {
+	positions := t.positionLookups()
+	if len(code) != len(positions) {
+		return codeErrorf(code, "expected %d letters", len(positions))
+	}
+	for i, position := range positions {
+		letter := code[i : i+1]
+		if letter == "-" {
+			continue
+		}
+		if _, ok := position.values[letter]; !ok {
+			return codeErrorf(code, "unknown %s %q", position.name, letter)
+		}
+	}
+	return nil
+}
+
+// validateWord checks a GNT word's text columns and code, and that the
+// decoded morphology encodes back to the same part and code
+func (t *Gnt) validateWord(parts []string, morphology models.GntMorphology) error {
+	for _, text := range parts[3:] {
+		if err := malformedText(text, isGreekText); err != nil {
+			return codeErrorf(text, "%s", err.Error())
+		}
+	}
+	if err := t.validateCode(parts[2]); err != nil {
+		return err
+	}
+	part, code, err := t.Encode(morphology)
+	if err != nil {
+		return codeErrorf(parts[1]+" "+parts[2], "%s", err.Error())
+	}
+	if part != parts[1] || code != parts[2] {
+		return codeErrorf(parts[1]+" "+parts[2], "encodes back as %s %s", part, code)
+	}
+	return nil
+}
@@ -1,80 +1,93 @@
 package parser
 
 import (
-	"fmt"
-	"os"
+	"context"
+	"errors"
 	"path"
-	"strings"
 
+	"github.com/davidbetz/morph/internal/canon"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/platform"
-	"github.com/davidbetz/morph/internal/util"
+	"github.com/davidbetz/morph/internal/versification"
 )
 
-type wlcBookData struct {
+// WlcBook is a parsed WLC book
+type WlcBook struct {
 	Name string
 	Data []models.WlcWord
 }
 
-func (t *Wlc) getTableName() string {
-	tableName := os.Getenv("TABLE_NAME")
-	if len(tableName) == 0 {
-		tableName = "morphwlc"
-	}
-	return tableName
-}
-
 func (t *Wlc) cleanStyle() string {
-	style := t.style
-	if style == "english" {
-		style = "remapped"
-		fmt.Println("Using English verses.")
-	} else {
-		style = "hebrew"
-		fmt.Println("Using Hebrew verses. Specify -style=english for the other mode.")
+	if t.style == "english" {
+		return "remapped"
 	}
-	return style
+	return "hebrew"
 }
 
-func (t *Wlc) readData(books chan *wlcBookData) {
-	folder := os.Getenv("SOURCE")
-	if len(folder) == 0 {
-		folder = "./morphhb/"
-	}
-	style := t.cleanStyle()
-	folder = path.Join(folder, style)
-	for n := 1; n < 40; n++ {
-		var bookName string
-		for name, number := range t.bookOrder {
-			if number == n {
-				bookName = name
-			}
+// Books parses each book under folder and passes it to fn in canonical
+// order, stopping before the next book once ctx is cancelled
+func (t *Wlc) Books(ctx context.Context, folder string, fn func(book *WlcBook) error) error {
+	t.reset()
+	var books []canon.Book
+	for n := 1; n <= canon.OldTestamentBooks; n++ {
+		if !t.selection.IncludesBook(n) {
+			continue
+		}
+		book, _ := canon.BookByNumber(n)
+		if t.skip != nil && t.skip(book.Name) {
+			continue
 		}
-		filename := strings.ToLower(strings.Replace(bookName, " ", "", -1))
-		words, err := t.ParseFileContent(bookName, path.Join(folder, filename+".json"))
+		books = append(books, book)
+	}
+	err := t.each(ctx, len(books), func(i int) (interface{}, error) {
+		words, err := t.readBook(folder, books[i])
 		if err != nil {
-			if err.Error() == "Skip" {
-				continue
+			if errors.Is(err, errSkip) {
+				return nil, nil
 			}
-			util.Errorf(err.Error())
+			return nil, err
 		}
-		books <- &wlcBookData{
-			bookName,
+		return &WlcBook{
+			books[i].Name,
 			words,
-		}
+		}, nil
+	}, func(book interface{}) error {
+		return fn(book.(*WlcBook))
+	})
+	if err != nil {
+		return err
 	}
-	close(books)
+	return t.result()
 }
 
-func (t *Wlc) Process() error {
-	books := make(chan *wlcBookData)
-	go t.readData(books)
-	for book := range books {
-		fmt.Printf("Parsed %s. Saving...\n", book.Name)
-		err := platform.PrepareAndPersistWlc(t.getTableName(), book.Name, book.Data)
-		if err != nil {
-			return err
+func (t *Wlc) readBook(folder string, book canon.Book) ([]models.WlcWord, error) {
+	if t.style != "both" {
+		return t.ParseFileContent(book.Name, path.Join(folder, t.cleanStyle(), book.Filename()+".json"))
+	}
+	hebrew, english, err := t.ParseBothStyles(folder, book)
+	if err != nil {
+		return nil, err
+	}
+	versification.Apply(hebrew, english, versification.Align(hebrew, english))
+	var words []models.WlcWord
+	for _, word := range hebrew {
+		if t.selection.Includes(word.Reference) {
+			words = append(words, word)
 		}
 	}
-	return platform.PostPersistWLC(t.getTableName())
+	return words, nil
+}
+
+// ParseBothStyles parses a book from both the hebrew and the remapped
+// (English versification) trees under folder
+func (t *Wlc) ParseBothStyles(folder string, book canon.Book) ([]models.WlcWord, []models.WlcWord, error) {
+	filename := book.Filename() + ".json"
+	hebrew, err := t.parseFile(book.Name, path.Join(folder, "hebrew", filename), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	english, err := t.parseFile(book.Name, path.Join(folder, "remapped", filename), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hebrew, english, nil
 }
@@ -1,30 +1,46 @@
-//go:build aws
-// +build aws
-
 package platform
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"time"
 
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
+	"github.com/davidbetz/morph/internal/retry"
 )
 
-func getPartitionSize() int {
+type awsSink struct {
+	tableName string
+	session   *session.Session
+	progress  *checkpoint.Checkpoint
+	workers   int
+	writer    *writer
+}
+
+func init() {
+	Register("aws", func() Sink { return &awsSink{} })
+}
+
+func (s *awsSink) getPartitionSize() int {
 	return 25
 }
 
-func createSession() (*session.Session, error) {
-	session, err := session.NewSession()
+func (s *awsSink) Open(ctx context.Context, config Config) error {
+	sess, err := session.NewSession()
 	if err != nil {
-		util.Errorf(err.Error())
+		return fmt.Errorf("NewSession error %s", err.Error())
 	}
-	return session, err
+	s.tableName = config.TableName
+	s.session = sess
+	s.progress = config.Checkpoint
+	s.workers = config.workers()
+	s.writer = newWriter("aws", config)
+	return nil
 }
 
 func createAttributeValue(word interface{}) (map[string]*dynamodb.AttributeValue, error) {
@@ -35,99 +51,95 @@ func createAttributeValue(word interface{}) (map[string]*dynamodb.AttributeValue
 	return av, nil
 }
 
-func unifiedPersist(tableName string, bookName string, words []interface{}) error {
-	prepared := make([]*dynamodb.WriteRequest, len(words))
-	for i, word := range words {
-		av, err := createAttributeValue(word)
-		if err != nil {
-			return err
+// unifiedPersist writes size records, marshalling each one straight from
+// its struct to an item as its partition is written
+func (s *awsSink) unifiedPersist(ctx context.Context, bookName string, size int, record func(i int) interface{}) error {
+	return partitionAndPersist(ctx, s.progress, "aws", bookName, size, s.getPartitionSize(), s.workers, func(ctx context.Context, low int, high int) error {
+		items := make([]*dynamodb.WriteRequest, 0, high-low)
+		for i := low; i < high; i++ {
+			av, err := createAttributeValue(record(i))
+			if err != nil {
+				return err
+			}
+			items = append(items, &dynamodb.WriteRequest{
+				PutRequest: &dynamodb.PutRequest{
+					Item: av,
+				},
+			})
 		}
-		prepared[i] = &dynamodb.WriteRequest{
-			PutRequest: &dynamodb.PutRequest{
-				Item: av,
-			},
-		}
-	}
-	return PartitionAndPersist(tableName, bookName, prepared)
+		return s.persist(ctx, items)
+	})
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *awsSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *awsSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PartitionAndPersist(tableName string, bookName string, prepared []*dynamodb.WriteRequest) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, len(prepared))
-	for idxRange := range util.Partition(len(prepared), PartitionSize) {
-		// fmt.Printf("Partition: %d %d %d\n", idxRange.Low, idxRange.High, idxRange.High-idxRange.Low)
-		segment := prepared[idxRange.Low:idxRange.High]
-		err := persist(tableName, segment)
+// awsItemLimit is the largest item DynamoDB accepts
+const awsItemLimit = 400 * 1024
+
+func (s *awsSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	for _, document := range documents {
+		m, err := json.Marshal(document)
 		if err != nil {
 			return err
 		}
-		percent := (float64(segmentNumber) * float64((PartitionSize)) / float64(len(prepared))) * 100
-		if percent > 100 {
-			percent = 100
+		if len(m) > awsItemLimit {
+			return fmt.Errorf("document %s is %d bytes but DynamoDB items are limited to %d; use a finer -granularity", document.ID, len(m), awsItemLimit)
 		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
 	}
-	return nil
-}
-
-func ValidateCloudConfig() error {
-	return nil
+	return s.unifiedPersist(ctx, bookName, len(documents), func(i int) interface{} {
+		return documents[i]
+	})
 }
 
-func persist(tableName string, items []*dynamodb.WriteRequest) error {
-	sess, err := createSession()
-	if err != nil {
-		return fmt.Errorf("NewSession error %s", err.Error())
-	}
-
-	records := make(map[string][]*dynamodb.WriteRequest, 1)
-	notdone := true
-	retry := 0
-	backoff := 1
-	for notdone {
-		records[tableName] = items
+// persist writes a batch, sending again whatever DynamoDB leaves
+// unprocessed until it's all written or the retry policy gives up
+func (s *awsSink) persist(ctx context.Context, items []*dynamodb.WriteRequest) error {
+	svc := dynamodb.New(s.session)
+	//+ a retry sends only the items left unprocessed
+	records := func() int { return len(items) }
+	return s.writer.write(ctx, records, awsRetryable, func() error {
 		input := &dynamodb.BatchWriteItemInput{
-			RequestItems: records,
+			RequestItems: map[string][]*dynamodb.WriteRequest{s.tableName: items},
 		}
-		svc := dynamodb.New(sess)
-		response, err := svc.BatchWriteItem(input)
+		response, err := svc.BatchWriteItemWithContext(ctx, input)
 		if err != nil {
 			return err
 		}
-		items = response.UnprocessedItems[tableName]
-		if len(items) == 0 {
-			notdone = false
-			continue
+		items = response.UnprocessedItems[s.tableName]
+		if len(items) > 0 {
+			return retry.Transient(fmt.Errorf("%d items unprocessed", len(items)))
 		}
-		retry++
-		time.Sleep(time.Duration(backoff) * time.Second)
-		fmt.Printf("DYNAMODB BACKING OFF (%d) | Left to process: %d | Backoff: %ds\n", retry, len(items), backoff)
-		backoff *= 2
-	}
+		return nil
+	})
+}
+
+// awsRetryable accepts throttling and the errors the SDK itself retries
+func awsRetryable(err error) bool {
+	return request.IsErrorThrottle(err) || request.IsErrorRetryable(err)
+}
+
+func (s *awsSink) PostPersistWlc(ctx context.Context) error {
+	return nil
+}
+
+func (s *awsSink) PostPersistGnt(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistWLC(tableName string) error {
+func (s *awsSink) PostPersistDocuments(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
+func (s *awsSink) Close() error {
 	return nil
 }
@@ -1,19 +1,20 @@
-//go:build azure
-// +build azure
-
 package platform
 
 //+ https://github.com/Azure/azure-sdk-for-go/blob/77258e94d84ea36012a72c0e0a1e2faa409c6396/storage/entity_test.go
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"net"
+	"net/http"
 	"os"
+	"unicode/utf16"
 
 	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
 )
 
 type azureWord struct {
@@ -22,38 +23,69 @@ type azureWord struct {
 	Properties   map[string]interface{}
 }
 
-func getTableReference(tableName string) *storage.Table {
-	cs := os.Getenv("CS")
-	client, err := storage.NewClientFromConnectionString(cs)
-	if err != nil {
-		log.Fatal(err)
-	}
-	tableService := client.GetTableService()
-	return tableService.GetTableReference(tableName)
+type azureSink struct {
+	table    *storage.Table
+	progress *checkpoint.Checkpoint
+	workers  int
+	writer   *writer
+}
+
+func init() {
+	Register("azure", func() Sink { return &azureSink{} })
 }
 
-func getPartitionSize() int {
+func (s *azureSink) getPartitionSize() int {
 	return 1000
 }
 
-func ValidateCloudConfig() error {
+func (s *azureSink) Open(ctx context.Context, config Config) error {
 	cs := os.Getenv("CS")
 	if len(cs) == 0 {
 		return errors.New("CS is required.")
 	}
+	client, err := storage.NewClientFromConnectionString(cs)
+	if err != nil {
+		return err
+	}
+	tableService := client.GetTableService()
+	s.table = tableService.GetTableReference(config.TableName)
+	s.progress = config.Checkpoint
+	s.workers = config.workers()
+	s.writer = newWriter("azure", config)
 	return nil
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
+func (s *azureSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
 	var prepared []azureWord
 	for _, word := range words {
 		preparedProperties := map[string]interface{}{
-			"Lemma":  word.Lemma,
-			"CoreID": word.ID,
+			"Lemma":                 word.Lemma,
+			"Consonantal":           word.Consonantal,
+			"Pointed":               word.Pointed,
+			"Normalized":            word.Normalized,
+			"Transliteration":       word.Transliteration,
+			"SimpleTransliteration": word.SimpleTransliteration,
+			"Accent":                word.Accent,
+			"Depth":                 word.Depth,
+			"Half":                  word.Half,
+			"Segment":               word.Segment,
+			"CoreID":                word.ID,
+			"Strongs":               word.StrongsID,
 			//+ separating each part to a different column creates far too many
-			"MorphCodes": word.MorphologyString,
-			"UniqueID":   word.Verse,
-			"Codes":      word.Codes,
+			"MorphCodes":  word.MorphologyString,
+			"UniqueID":    word.Verse,
+			"Codes":       word.Codes,
+			"Parsing":     word.Parsing,
+			"Morpheme":    word.Morpheme,
+			"Book":        word.Reference.Book,
+			"Chapter":     word.Reference.Chapter,
+			"VerseNumber": word.Reference.Verse,
+		}
+		if english := word.EnglishReference; english != nil {
+			preparedProperties["EnglishVerse"] = word.EnglishVerse
+			preparedProperties["EnglishChapter"] = english.Chapter
+			preparedProperties["EnglishVerseNumber"] = english.Verse
+			preparedProperties["EnglishWord"] = english.Word
 		}
 		prepared = append(prepared, azureWord{
 			PartitionKey: word.Verse,
@@ -61,63 +93,101 @@ func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcW
 			Properties:   preparedProperties,
 		})
 	}
-	return PartitionAndPersist(tableName, bookName, prepared)
+	return s.partitionAndPersist(ctx, bookName, prepared)
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
+func (s *azureSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
 	var prepared []azureWord
 	for _, word := range words {
 		prepared = append(prepared, azureWord{
 			PartitionKey: word.Verse,
 			RowKey:       fmt.Sprintf("%d", word.ID),
 			Properties: map[string]interface{}{
-				"Part":       word.Morphology.Part,
-				"Person":     word.Morphology.Person,
-				"Tense":      word.Morphology.Tense,
-				"Voice":      word.Morphology.Voice,
-				"Mood":       word.Morphology.Mood,
-				"Case":       word.Morphology.Case,
-				"Number":     word.Morphology.Number,
-				"Gender":     word.Morphology.Gender,
-				"Degree":     word.Morphology.Degree,
-				"Text":       word.Text,
-				"Word":       word.Word,
-				"Normalized": word.Normalized,
-				"Lemma":      word.Lemma,
-				"Codes":      word.Codes,
+				"Part":                 word.Morphology.Part,
+				"Person":               word.Morphology.Person,
+				"Tense":                word.Morphology.Tense,
+				"Voice":                word.Morphology.Voice,
+				"Mood":                 word.Morphology.Mood,
+				"Case":                 word.Morphology.Case,
+				"Number":               word.Morphology.Number,
+				"Gender":               word.Morphology.Gender,
+				"Degree":               word.Morphology.Degree,
+				"Text":                 word.Text,
+				"Word":                 word.Word,
+				"Normalized":           word.Normalized,
+				"Lemma":                word.Lemma,
+				"Codes":                word.Codes,
+				"Parsing":              word.Parsing,
+				"Leading":              word.Leading,
+				"Trailing":             word.Trailing,
+				"Sentence":             word.Sentence,
+				"Clause":               word.Clause,
+				"Folded":               word.Folded,
+				"LemmaFolded":          word.LemmaFolded,
+				"BetaCode":             word.BetaCode,
+				"LemmaBetaCode":        word.LemmaBetaCode,
+				"Transliteration":      word.Transliteration,
+				"LemmaTransliteration": word.LemmaTransliteration,
+				"Book":                 word.Reference.Book,
+				"Chapter":              word.Reference.Chapter,
+				"VerseNumber":          word.Reference.Verse,
 			},
 		})
 	}
-	return PartitionAndPersist(tableName, bookName, prepared)
+	return s.partitionAndPersist(ctx, bookName, prepared)
 }
 
-func PartitionAndPersist(tableName string, bookName string, prepared []azureWord) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, len(prepared))
-	for idxRange := range util.Partition(len(prepared), PartitionSize) {
-		segment := prepared[idxRange.Low:idxRange.High]
-		err := persist(tableName, segment)
+// azurePropertyLimit is the largest string property Azure Table accepts,
+// in bytes of UTF-16
+const azurePropertyLimit = 64 * 1024
+
+func (s *azureSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	var prepared []azureWord
+	for _, document := range documents {
+		words, err := json.Marshal(document.Words)
 		if err != nil {
 			return err
 		}
-		percent := ((float64(segmentNumber) * float64((PartitionSize)) / float64(len(prepared))) * 100)
-		if percent > 100 {
-			percent = 100
+		if size := len(utf16.Encode([]rune(string(words)))) * 2; size > azurePropertyLimit {
+			return fmt.Errorf("document %s has %d bytes of words but Azure Table properties are limited to %d; use a finer -granularity", document.ID, size, azurePropertyLimit)
 		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
+		prepared = append(prepared, azureWord{
+			PartitionKey: fmt.Sprintf("%02d", document.Book),
+			RowKey:       document.ID,
+			Properties: map[string]interface{}{
+				"Granularity": document.Granularity,
+				"Book":        document.Book,
+				"BookID":      document.BookID,
+				"Chapter":     document.Chapter,
+				"VerseNumber": document.Verse,
+				"Text":        document.Text,
+				"Words":       string(words),
+			},
+		})
 	}
-	return nil
+	return s.partitionAndPersist(ctx, bookName, prepared)
 }
 
-func persist(tableName string, segment []azureWord) error {
+func (s *azureSink) partitionAndPersist(ctx context.Context, bookName string, prepared []azureWord) error {
+	return partitionAndPersist(ctx, s.progress, "azure", bookName, len(prepared), s.getPartitionSize(), s.workers, func(ctx context.Context, low int, high int) error {
+		return s.persist(ctx, prepared[low:high])
+	})
+}
+
+// persist writes one entity at a time; the storage client takes no
+// context, so cancellation is checked between entities. InsertOrReplace
+// makes writing a partition again harmless.
+func (s *azureSink) persist(ctx context.Context, segment []azureWord) error {
 	for _, word := range segment {
-		table := getTableReference(tableName)
-		entity := table.GetEntityReference(word.PartitionKey, word.RowKey)
+		err := ctx.Err()
+		if err != nil {
+			return err
+		}
+		entity := s.table.GetEntityReference(word.PartitionKey, word.RowKey)
 		entity.Properties = word.Properties
-		err := entity.InsertOrReplace(nil)
+		err = s.writer.write(ctx, func() int { return 1 }, azureRetryable, func() error {
+			return entity.InsertOrReplace(nil)
+		})
 		if err != nil {
 			return err
 		}
@@ -125,10 +195,38 @@ func persist(tableName string, segment []azureWord) error {
 	return nil
 }
 
-func PostPersistWLC(tableName string) error {
+// azureRetryable accepts timeouts, throttling and server errors
+func azureRetryable(err error) bool {
+	status := 0
+	var service storage.AzureStorageServiceError
+	var unexpected storage.UnexpectedStatusCodeError
+	switch {
+	case errors.As(err, &service):
+		status = service.StatusCode
+	case errors.As(err, &unexpected):
+		status = unexpected.Got()
+	}
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (s *azureSink) PostPersistWlc(ctx context.Context) error {
+	return nil
+}
+
+func (s *azureSink) PostPersistGnt(ctx context.Context) error {
+	return nil
+}
+
+func (s *azureSink) PostPersistDocuments(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
+func (s *azureSink) Close() error {
 	return nil
 }
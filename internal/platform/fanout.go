@@ -0,0 +1,172 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+type fanoutTarget struct {
+	name   string
+	sink   Sink
+	books  int
+	failed string
+	err    error
+}
+
+// Fanout delivers every book to several sinks. A sink that fails is
+// dropped for the rest of the run while the others keep going.
+type Fanout struct {
+	targets []*fanoutTarget
+}
+
+// CreateFanout creates a Fanout over the sinks registered under names
+func CreateFanout(names []string) (*Fanout, error) {
+	f := &Fanout{}
+	for _, name := range names {
+		sink, err := Create(name)
+		if err != nil {
+			return nil, err
+		}
+		f.targets = append(f.targets, &fanoutTarget{name: name, sink: sink})
+	}
+	if len(f.targets) == 0 {
+		return nil, fmt.Errorf("no sink specified: %s", strings.Join(Names(), "|"))
+	}
+	return f, nil
+}
+
+func (f *Fanout) each(stage string, fn func(sink Sink) error) error {
+	active := 0
+	for _, target := range f.targets {
+		if target.err != nil {
+			continue
+		}
+		err := fn(target.sink)
+		//+ cancelling stops the run rather than failing the sink
+		if cancelled(err) {
+			return err
+		}
+		if err != nil {
+			fmt.Printf("[%s] FAILED during %s: %s\n", target.name, stage, err.Error())
+			target.failed = stage
+			target.err = err
+			continue
+		}
+		active++
+	}
+	if active == 0 {
+		return f.Report()
+	}
+	return nil
+}
+
+// cancelled reports whether err comes from the run's context ending
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (f *Fanout) Open(ctx context.Context, config Config) error {
+	return f.each("open", func(sink Sink) error {
+		return sink.Open(ctx, config)
+	})
+}
+
+func (f *Fanout) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
+	return f.persistBook(bookName, func(sink Sink) error {
+		return sink.PrepareAndPersistWlc(ctx, bookName, words)
+	})
+}
+
+func (f *Fanout) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
+	return f.persistBook(bookName, func(sink Sink) error {
+		return sink.PrepareAndPersistGnt(ctx, bookName, words)
+	})
+}
+
+func (f *Fanout) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	return f.persistBook(bookName, func(sink Sink) error {
+		return sink.PrepareAndPersistDocuments(ctx, bookName, documents)
+	})
+}
+
+func (f *Fanout) persistBook(bookName string, fn func(sink Sink) error) error {
+	err := f.each(bookName, fn)
+	if err != nil {
+		return err
+	}
+	for _, target := range f.targets {
+		if target.err == nil {
+			target.books++
+		}
+	}
+	return nil
+}
+
+func (f *Fanout) PostPersistWlc(ctx context.Context) error {
+	return f.postPersist(func(sink Sink) error {
+		return sink.PostPersistWlc(ctx)
+	})
+}
+
+func (f *Fanout) PostPersistGnt(ctx context.Context) error {
+	return f.postPersist(func(sink Sink) error {
+		return sink.PostPersistGnt(ctx)
+	})
+}
+
+func (f *Fanout) PostPersistDocuments(ctx context.Context) error {
+	return f.postPersist(func(sink Sink) error {
+		return sink.PostPersistDocuments(ctx)
+	})
+}
+
+// postPersist runs fn on every sink still going and reports the outcome.
+// A cancelled run returns the cancellation, so it isn't taken for a
+// finished one.
+func (f *Fanout) postPersist(fn func(sink Sink) error) error {
+	err := f.each("post-persist", fn)
+	if cancelled(err) {
+		return err
+	}
+	return f.Report()
+}
+
+func (f *Fanout) Close() error {
+	var failed []string
+	for _, target := range f.targets {
+		if target.sink == nil {
+			continue
+		}
+		err := target.sink.Close()
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", target.name, err.Error()))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("close failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Report prints the outcome of every sink and returns an error listing the
+// sinks that failed, or nil when all of them succeeded
+func (f *Fanout) Report() error {
+	var failed []string
+	fmt.Println("Sink report:")
+	for _, target := range f.targets {
+		if target.err != nil {
+			fmt.Printf("  %s: FAILED during %s after %d books: %s\n", target.name, target.failed, target.books, target.err.Error())
+			failed = append(failed, fmt.Sprintf("%s (%s): %s", target.name, target.failed, target.err.Error()))
+			continue
+		}
+		fmt.Printf("  %s: ok (%d books)\n", target.name, target.books)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d sinks failed: %s", len(failed), len(f.targets), strings.Join(failed, "; "))
+	}
+	return nil
+}
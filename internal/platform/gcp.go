@@ -1,57 +1,132 @@
-//go:build gcp
-// +build gcp
-
 package platform
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 
 	"cloud.google.com/go/datastore"
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type wlcWordDataStoreEntity struct {
-	Codes      string `datastore:"codes"`
-	Language   string `datastore:"language"`
-	Lemma      string `datastore:"lemma"`
-	ID         string `datastore:"coreid"`
-	Morphology string `datastore:"morphology"`
-	SequenceID int64  `datastore:"id"`
-	Verse      string `datastore:"verse"`
+	Codes                 string `datastore:"codes"`
+	Language              string `datastore:"language"`
+	Lemma                 string `datastore:"lemma"`
+	Consonantal           string `datastore:"consonantal"`
+	Pointed               string `datastore:"pointed"`
+	Normalized            string `datastore:"normalized"`
+	Transliteration       string `datastore:"transliteration"`
+	SimpleTransliteration string `datastore:"simpleTransliteration"`
+	Accent                string `datastore:"accent"`
+	Depth                 int    `datastore:"depth"`
+	Half                  int    `datastore:"half"`
+	Segment               string `datastore:"segment"`
+	ID                    string `datastore:"coreid"`
+	Strongs               string `datastore:"strongs"`
+	Morphology            string `datastore:"morphology"`
+	Parsing               string `datastore:"parsing"`
+	Morpheme              int    `datastore:"morpheme"`
+	SequenceID            int64  `datastore:"id"`
+	Verse                 string `datastore:"verse"`
+	Book                  int    `datastore:"book"`
+	Chapter               int    `datastore:"chapter"`
+	VerseNum              int    `datastore:"verseNumber"`
+	EnglishVerse          string `datastore:"englishVerse"`
+	EnglishChapter        int    `datastore:"englishChapter"`
+	EnglishVerseNum       int    `datastore:"englishVerseNumber"`
+	EnglishWord           int    `datastore:"englishWord"`
+}
+
+type documentDataStoreEntity struct {
+	Granularity string `datastore:"granularity"`
+	Book        int    `datastore:"book"`
+	BookID      string `datastore:"bookId"`
+	Chapter     int    `datastore:"chapter"`
+	VerseNum    int    `datastore:"verseNumber"`
+	Text        string `datastore:"text,noindex"`
+	Words       string `datastore:"words,noindex"`
 }
 
+// gcpEntityLimit is the largest entity Datastore accepts
+const gcpEntityLimit = 1024 * 1024
+
 type saver func(context.Context, int, int, *datastore.Client) ([]*datastore.Key, error)
 
-func getPartitionSize() int {
+type gcpSink struct {
+	tableName string
+	client    *datastore.Client
+	progress  *checkpoint.Checkpoint
+	workers   int
+	writer    *writer
+}
+
+func init() {
+	Register("gcp", func() Sink { return &gcpSink{} })
+}
+
+func (s *gcpSink) getPartitionSize() int {
 	return 200
 }
 
-func ValidateCloudConfig() error {
+func (s *gcpSink) Open(ctx context.Context, config Config) error {
 	projectID := os.Getenv("PROJECT_ID")
 	if len(projectID) == 0 {
 		return errors.New("PROJECT_ID is required.")
 	}
+	client, err := datastore.NewClient(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	s.tableName = config.TableName
+	s.client = client
+	s.progress = config.Checkpoint
+	s.workers = config.workers()
+	s.writer = newWriter("gcp", config)
 	return nil
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
+func (s *gcpSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
 	var keys []*datastore.Key
 	var prepared []wlcWordDataStoreEntity
 	for _, word := range words {
-		keys = append(keys, datastore.NameKey(tableName, fmt.Sprintf("%d", word.SequenceID), nil))
-		prepared = append(prepared, wlcWordDataStoreEntity{
-			Codes:      word.Codes,
-			Language:   word.Language,
-			Lemma:      word.Lemma,
-			ID:         word.ID,
-			Morphology: word.MorphologyString,
-			SequenceID: word.SequenceID,
-			Verse:      word.Verse,
-		})
+		keys = append(keys, datastore.NameKey(s.tableName, fmt.Sprintf("%d", word.SequenceID), nil))
+		entity := wlcWordDataStoreEntity{
+			Codes:                 word.Codes,
+			Language:              word.Language,
+			Lemma:                 word.Lemma,
+			Consonantal:           word.Consonantal,
+			Pointed:               word.Pointed,
+			Normalized:            word.Normalized,
+			Transliteration:       word.Transliteration,
+			SimpleTransliteration: word.SimpleTransliteration,
+			Accent:                word.Accent,
+			Depth:                 word.Depth,
+			Half:                  word.Half,
+			Segment:               word.Segment,
+			ID:                    word.ID,
+			Strongs:               word.StrongsID,
+			Morphology:            word.MorphologyString,
+			Parsing:               word.Parsing,
+			Morpheme:              word.Morpheme,
+			SequenceID:            word.SequenceID,
+			Verse:                 word.Verse,
+			Book:                  word.Reference.Book,
+			Chapter:               word.Reference.Chapter,
+			VerseNum:              word.Reference.Verse,
+			EnglishVerse:          word.EnglishVerse,
+		}
+		if english := word.EnglishReference; english != nil {
+			entity.EnglishChapter = english.Chapter
+			entity.EnglishVerseNum = english.Verse
+			entity.EnglishWord = english.Word
+		}
+		prepared = append(prepared, entity)
 	}
 	//+ strategy pattern bc of different types
 	f := func(ctx context.Context, start int, end int, client *datastore.Client) ([]*datastore.Key, error) {
@@ -61,13 +136,13 @@ func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcW
 		}
 		return results, nil
 	}
-	return PartitionAndPersist(tableName, bookName, len(prepared), f)
+	return s.partitionAndPersist(ctx, bookName, len(prepared), f)
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
+func (s *gcpSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
 	var keys []*datastore.Key
 	for _, key := range words {
-		keys = append(keys, datastore.NameKey(tableName, fmt.Sprintf("%d", key.ID), nil))
+		keys = append(keys, datastore.NameKey(s.tableName, fmt.Sprintf("%d", key.ID), nil))
 	}
 	f := func(ctx context.Context, start int, end int, client *datastore.Client) ([]*datastore.Key, error) {
 		results, err := client.PutMulti(ctx, keys[start:end], words[start:end])
@@ -76,50 +151,87 @@ func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntW
 		}
 		return results, nil
 	}
-	return PartitionAndPersist(tableName, bookName, len(words), f)
+	return s.partitionAndPersist(ctx, bookName, len(words), f)
 }
 
-func PartitionAndPersist(tableName string, bookName string, size int, f saver) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, size)
-	for idxRange := range util.Partition(size, PartitionSize) {
-		err := persist(idxRange.Low, idxRange.High, f)
+func (s *gcpSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	var keys []*datastore.Key
+	var prepared []documentDataStoreEntity
+	for _, document := range documents {
+		words, err := json.Marshal(document.Words)
 		if err != nil {
 			return err
 		}
-		percent := (float64(segmentNumber) * float64((PartitionSize)) / float64(size)) * 100
-		if percent > 100 {
-			percent = 100
+		if size := len(words) + len(document.Text); size > gcpEntityLimit {
+			return fmt.Errorf("document %s is %d bytes but Datastore entities are limited to %d; use a finer -granularity", document.ID, size, gcpEntityLimit)
 		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
+		keys = append(keys, datastore.NameKey(s.tableName, document.ID, nil))
+		prepared = append(prepared, documentDataStoreEntity{
+			Granularity: document.Granularity,
+			Book:        document.Book,
+			BookID:      document.BookID,
+			Chapter:     document.Chapter,
+			VerseNum:    document.Verse,
+			Text:        document.Text,
+			Words:       string(words),
+		})
 	}
-	return nil
+	f := func(ctx context.Context, start int, end int, client *datastore.Client) ([]*datastore.Key, error) {
+		return client.PutMulti(ctx, keys[start:end], prepared[start:end])
+	}
+	return s.partitionAndPersist(ctx, bookName, len(prepared), f)
 }
 
-func persist(start int, end int, f saver) error {
-	ctx := context.Background()
-	projectID := os.Getenv("PROJECT_ID")
-	client, err := datastore.NewClient(ctx, projectID)
-	if err != nil {
-		return err
-	}
+func (s *gcpSink) partitionAndPersist(ctx context.Context, bookName string, size int, f saver) error {
+	return partitionAndPersist(ctx, s.progress, "gcp", bookName, size, s.getPartitionSize(), s.workers, func(ctx context.Context, low int, high int) error {
+		return s.persist(ctx, low, high, f)
+	})
+}
+
+func (s *gcpSink) persist(ctx context.Context, start int, end int, f saver) error {
 	if f == nil {
 		return errors.New("f is nil")
 	}
-	_, err = f(ctx, start, end, client)
-	if err != nil {
+	return s.writer.write(ctx, func() int { return end - start }, gcpRetryable, func() error {
+		_, err := f(ctx, start, end, s.client)
 		return err
+	})
+}
+
+// gcpRetryable accepts the gRPC codes Datastore returns for contention,
+// quota and outages, including any among the errors of a batch
+func gcpRetryable(err error) bool {
+	var multi datastore.MultiError
+	if errors.As(err, &multi) {
+		for _, e := range multi {
+			if e != nil && gcpRetryable(e) {
+				return true
+			}
+		}
+		return false
 	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded, codes.Internal:
+		return true
+	}
+	return false
+}
+
+func (s *gcpSink) PostPersistWlc(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistWLC(tableName string) error {
+func (s *gcpSink) PostPersistGnt(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
+func (s *gcpSink) PostPersistDocuments(ctx context.Context) error {
 	return nil
 }
+
+func (s *gcpSink) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
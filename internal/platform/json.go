@@ -1,104 +1,149 @@
-//go:build json
-// +build json
-
 package platform
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path"
 
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
 )
 
-func getPartitionSize() int {
+type jsonSink struct {
+	folder   string
+	progress *checkpoint.Checkpoint
+	//+ files written to by this run, already cut back to their checkpoint
+	started map[string]bool
+}
+
+func init() {
+	Register("json", func() Sink { return &jsonSink{} })
+}
+
+func (s *jsonSink) getPartitionSize() int {
 	return 100
 }
 
-func ValidateCloudConfig() error {
+func (s *jsonSink) Open(ctx context.Context, config Config) error {
+	s.folder = path.Join("./output", config.TableName)
+	s.progress = config.Checkpoint
+	s.started = make(map[string]bool)
 	return nil
 }
 
-func unifiedPersist(tableName string, bookName string, words []interface{}) error {
-	prepared := make([][]byte, len(words))
-	for _, word := range words {
-		output, err := json.Marshal(word)
-		if err != nil {
-			return err
-		}
-		output = append(output, byte('\n'))
-		prepared = append(prepared, output)
-	}
-	err := PartitionAndPersist(tableName, bookName, prepared)
-	if err != nil {
-		return err
-	}
-	return nil
+// unifiedPersist writes size records, encoding each one once as its
+// partition is written
+func (s *jsonSink) unifiedPersist(ctx context.Context, bookName string, size int, record func(i int) interface{}) error {
+	//+ one writer keeps the lines of the file in order
+	return partitionAndPersist(ctx, s.progress, "json", bookName, size, s.getPartitionSize(), 1, func(ctx context.Context, low int, high int) error {
+		return s.persist(bookName, low, high, record)
+	})
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
-	//+ trick to unify the logic; fine when perf isn't an issue
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *jsonSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *jsonSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PartitionAndPersist(tableName string, bookName string, prepared [][]byte) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, len(prepared))
-	for idxRange := range util.Partition(len(prepared), PartitionSize) {
-		// fmt.Printf("Partition: %d %d %d\n", idxRange.Low, idxRange.High, idxRange.High-idxRange.Low)
-		segment := prepared[idxRange.Low:idxRange.High]
-		err := persist(tableName, bookName, segment)
+func (s *jsonSink) persist(bookName string, low int, high int, record func(i int) interface{}) error {
+	if _, err := os.Stat(s.folder); os.IsNotExist(err) {
+		os.MkdirAll(s.folder, 0777)
+	}
+	filename := path.Join(s.folder, bookName) + ".jsonl"
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	//+ the first partition a run writes drops whatever follows the records
+	//+ already checkpointed, such as part of a partition a crash cut short,
+	//+ so -resume doesn't write it twice
+	if !s.started[filename] {
+		offset, err := lineOffset(filename, low)
 		if err != nil {
 			return err
 		}
-		percent := (float64(segmentNumber) * float64((PartitionSize)) / float64(len(prepared))) * 100
-		if percent > 100 {
-			percent = 100
+		if err = f.Truncate(offset); err != nil {
+			return err
+		}
+		s.started[filename] = true
+	}
+	if _, err = f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+	encoder := json.NewEncoder(writer)
+	for i := low; i < high; i++ {
+		//+ Encode ends each record with the newline of a JSON line
+		if err = encoder.Encode(record(i)); err != nil {
+			return err
 		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
 	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	f.Sync()
 	return nil
 }
 
-func persist(tableName string, bookName string, words [][]byte) error {
-	folder := path.Join("./output", tableName)
-	if _, err := os.Stat(folder); os.IsNotExist(err) {
-		os.MkdirAll(folder, 0777)
+// lineOffset finds where line lines+1 of a JSON lines file starts
+func lineOffset(filename string, lines int) (int64, error) {
+	if lines == 0 {
+		return 0, nil
 	}
-	filename := path.Join(folder, bookName) + ".jsonl"
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	f, err := os.Open(filename)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer f.Close()
-	for _, word := range words {
-		if _, err = f.Write(word); err != nil {
-			return err
+	reader := bufio.NewReader(f)
+	var offset int64
+	for n := 0; n < lines; n++ {
+		line, err := reader.ReadSlice('\n')
+		offset += int64(len(line))
+		if err == bufio.ErrBufferFull {
+			n--
+			continue
+		}
+		if err == io.EOF {
+			return 0, fmt.Errorf("%s has %d records but the checkpoint has %d", filename, n, lines)
+		}
+		if err != nil {
+			return 0, err
 		}
 	}
-	f.Sync()
+	return offset, nil
+}
+
+func (s *jsonSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	return s.unifiedPersist(ctx, bookName, len(documents), func(i int) interface{} {
+		return documents[i]
+	})
+}
+
+func (s *jsonSink) PostPersistWlc(ctx context.Context) error {
+	return nil
+}
+
+func (s *jsonSink) PostPersistGnt(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistWLC(tableName string) error {
+func (s *jsonSink) PostPersistDocuments(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
+func (s *jsonSink) Close() error {
 	return nil
 }
@@ -0,0 +1,98 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davidbetz/morph/internal/checkpoint"
+)
+
+func TestLineOffset(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	tests := []struct {
+		name    string
+		content string
+		lines   int
+		offset  int64
+		err     string
+	}{
+		{"none", "a\nb\n", 0, 0, ""},
+		{"one", "a\nbb\nccc\n", 1, 2, ""},
+		{"all", "a\nbb\nccc\n", 3, 9, ""},
+		{"partial line after", "a\nbb\ncc", 2, 5, ""},
+		{"longer than the buffer", long + "\nb\n", 1, 10001, ""},
+		{"too few", "a\nbb\n", 3, 0, "has 2 records but the checkpoint has 3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "book.jsonl")
+			err := os.WriteFile(filename, []byte(test.content), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			offset, err := lineOffset(filename, test.lines)
+			if len(test.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %d, %v; want error containing %q", offset, err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if offset != test.offset {
+				t.Errorf("got %d, want %d", offset, test.offset)
+			}
+		})
+	}
+}
+
+// TestJsonResume writes a book whose last run stopped partway through a
+// partition after checkpointing one, and resumes it
+func TestJsonResume(t *testing.T) {
+	folder := t.TempDir()
+	progress, err := checkpoint.Open(filepath.Join(folder, "checkpoint.json"), checkpoint.Run{Mode: "wlc", Sinks: []string{"json"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = progress.SavePartition("json", "Ruth", 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	var stale strings.Builder
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&stale, "%d\n", i)
+	}
+	stale.WriteString("15")
+	err = os.WriteFile(filepath.Join(folder, "Ruth.jsonl"), []byte(stale.String()), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &jsonSink{folder: folder, progress: progress, started: make(map[string]bool)}
+	err = s.unifiedPersist(context.Background(), "Ruth", 250, func(i int) interface{} {
+		return i
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(folder, "Ruth.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 250 {
+		t.Fatalf("got %d lines, want 250", len(lines))
+	}
+	for i, line := range lines {
+		if line != fmt.Sprint(i) {
+			t.Fatalf("line %d is %q", i+1, line)
+		}
+	}
+	if got := progress.Partitions("json", "Ruth"); got != 3 {
+		t.Errorf("got %d partitions checkpointed, want 3", got)
+	}
+}
@@ -1,18 +1,19 @@
-//go:build mssql
-// +build mssql
-
 package platform
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
 	_ "github.com/denisenkom/go-mssqldb"
 	mssql "github.com/denisenkom/go-mssqldb"
 )
@@ -33,11 +34,21 @@ const (
 			(
 				WordID AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.id')),
 				VerseID AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.verse')),
+				Book AS CONVERT(int, JSON_VALUE(Content, '$.reference.book')),
+				Chapter AS CONVERT(int, JSON_VALUE(Content, '$.reference.chapter')),
+				VerseNumber AS CONVERT(int, JSON_VALUE(Content, '$.reference.verse')),
 				Text AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.text')),
 				mssqlWord AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.word')),
 				Normalized AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.normalized')),
 				Lemma AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.lemma')),
+				Sentence AS CONVERT(bigint, JSON_VALUE(Content, '$.sentence')),
+				Clause AS CONVERT(bigint, JSON_VALUE(Content, '$.clause')),
+				Folded AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.folded')),
+				LemmaFolded AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.lemmaFolded')),
+				BetaCode AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.betaCode')),
+				Transliteration AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.transliteration')),
 				Codes AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.codes')),
+				Parsing AS CONVERT(nvarchar(400), JSON_VALUE(Content, '$.parsing')),
 				Content [nvarchar](max) NOT NULL
 			);
 		
@@ -47,6 +58,9 @@ const (
 		END`
 	createGNTIndexes = `
 	CREATE CLUSTERED INDEX Index{{ TABLE_NAME }}WordID ON {{ TABLE_NAME }} (WordID);
+	CREATE INDEX Index{{ TABLE_NAME }}Sentence ON {{ TABLE_NAME }} (Sentence);
+	CREATE INDEX Index{{ TABLE_NAME }}Folded ON {{ TABLE_NAME }} (Folded);
+	CREATE INDEX Index{{ TABLE_NAME }}LemmaFolded ON {{ TABLE_NAME }} (LemmaFolded);
 	ALTER TABLE [dbo].{{ TABLE_NAME }} ADD CONSTRAINT {{ TABLE_NAME }}ContentJson CHECK (ISJSON(Content)=1);
 	`
 
@@ -65,15 +79,55 @@ const (
 				(
 					WordID AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.id')),
 					Verse AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.verse')),
+					Book AS CONVERT(int, JSON_VALUE(Content, '$.reference.book')),
+					Chapter AS CONVERT(int, JSON_VALUE(Content, '$.reference.chapter')),
+					VerseNumber AS CONVERT(int, JSON_VALUE(Content, '$.reference.verse')),
 					CoreID AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.coreid')),
+					Strongs AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.strongsId')),
 					Language AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.language')),
 					Lemma AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.lemma')),
+					Consonantal AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.consonantal')),
+					Pointed AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.pointed')),
+					Normalized AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.normalized')),
+					Half AS CONVERT(int, JSON_VALUE(Content, '$.half')),
+					Segment AS CONVERT(nvarchar(20), JSON_VALUE(Content, '$.segment')),
 					Codes AS CONVERT(nvarchar(200), JSON_VALUE(Content, '$.codes')),
+					Parsing AS CONVERT(nvarchar(400), JSON_VALUE(Content, '$.parsing')),
 					Content [nvarchar](max) NOT NULL
 				);			
 			END`
 	createWLCIndexes = `
 	CREATE CLUSTERED INDEX Index{{ TABLE_NAME }}WordID ON {{ TABLE_NAME }} (WordID);
+	CREATE INDEX Index{{ TABLE_NAME }}Consonantal ON {{ TABLE_NAME }} (Consonantal);
+	CREATE INDEX Index{{ TABLE_NAME }}Pointed ON {{ TABLE_NAME }} (Pointed);
+	CREATE INDEX Index{{ TABLE_NAME }}Normalized ON {{ TABLE_NAME }} (Normalized);
+	CREATE INDEX Index{{ TABLE_NAME }}Segment ON {{ TABLE_NAME }} (Verse, Segment);
+	ALTER TABLE [dbo].{{ TABLE_NAME }} ADD CONSTRAINT {{ TABLE_NAME }}ContentJson CHECK (ISJSON(Content)=1);
+	`
+
+	createDocumentTable = `IF EXISTS (SELECT 0
+			FROM INFORMATION_SCHEMA.TABLES
+			WHERE TABLE_SCHEMA = 'dbo' AND TABLE_NAME = '{{ TABLE_NAME }}')
+			BEGIN
+				PRINT '{{ TABLE_NAME }} exists.'
+			END
+			ELSE
+			BEGIN
+				PRINT 'Creating {{ TABLE_NAME }}...'
+				SET QUOTED_IDENTIFIER ON;
+			
+				CREATE TABLE [dbo].{{ TABLE_NAME }}
+				(
+					DocumentID AS CONVERT(nvarchar(20), JSON_VALUE(Content, '$.id')),
+					Granularity AS CONVERT(nvarchar(20), JSON_VALUE(Content, '$.granularity')),
+					Book AS CONVERT(int, JSON_VALUE(Content, '$.book')),
+					Chapter AS CONVERT(int, JSON_VALUE(Content, '$.chapter')),
+					VerseNumber AS CONVERT(int, JSON_VALUE(Content, '$.verse')),
+					Content [nvarchar](max) NOT NULL
+				);			
+			END`
+	createDocumentIndexes = `
+	CREATE CLUSTERED INDEX Index{{ TABLE_NAME }}DocumentID ON {{ TABLE_NAME }} (DocumentID);
 	ALTER TABLE [dbo].{{ TABLE_NAME }} ADD CONSTRAINT {{ TABLE_NAME }}ContentJson CHECK (ISJSON(Content)=1);
 	`
 )
@@ -83,134 +137,162 @@ type mssqlWord struct {
 	Data string
 }
 
-func createConnection() (*sql.DB, error) {
-	cs := os.Getenv("CS")
-	connection, err := sql.Open("mssql", cs)
-	if err != nil {
-		return nil, err
-	}
-	return connection, nil
+type mssqlSink struct {
+	tableName string
+	db        *sql.DB
+	progress  *checkpoint.Checkpoint
+	writer    *writer
 }
 
-func getPartitionSize() int {
+func init() {
+	Register("mssql", func() Sink { return &mssqlSink{} })
+}
+
+func (s *mssqlSink) getPartitionSize() int {
 	return 1000
 }
 
-func ValidateCloudConfig() error {
+func (s *mssqlSink) Open(ctx context.Context, config Config) error {
 	cs := os.Getenv("CS")
 	if len(cs) == 0 {
 		return errors.New("CS is required")
 	}
-	return nil
-}
-
-func PostPersistWLC(tableName string) error {
-	db, err := createConnection()
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-	sql := strings.Replace(createWLCIndexes, "{{ TABLE_NAME }}", tableName, -1)
-	_, err = db.Exec(sql)
+	connection, err := sql.Open("mssql", cs)
 	if err != nil {
 		return err
 	}
+	s.tableName = config.TableName
+	s.db = connection
+	s.progress = config.Checkpoint
+	s.writer = newWriter("mssql", config)
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
-	db, err := createConnection()
+func (s *mssqlSink) PostPersistWlc(ctx context.Context) error {
+	sql := strings.Replace(createWLCIndexes, "{{ TABLE_NAME }}", s.tableName, -1)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-	sql := strings.Replace(createGNTIndexes, "{{ TABLE_NAME }}", tableName, -1)
+	return nil
+}
+
+func (s *mssqlSink) PostPersistGnt(ctx context.Context) error {
+	sql := strings.Replace(createGNTIndexes, "{{ TABLE_NAME }}", s.tableName, -1)
 	fmt.Println(sql)
-	_, err = db.Exec(sql)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
-	db, err := createConnection()
+func (s *mssqlSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
+	sql := strings.Replace(createWLCTable, "{{ TABLE_NAME }}", s.tableName, -1)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-	sql := strings.Replace(createWLCTable, "{{ TABLE_NAME }}", tableName, -1)
-	_, err = db.Exec(sql)
+	return s.partitionAndPersist(ctx, bookName, len(words), func(i int) (mssqlWord, error) {
+		m, err := json.Marshal(words[i])
+		return mssqlWord{ID: words[i].SequenceID, Data: string(m)}, err
+	})
+}
+
+func (s *mssqlSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
+	sql := strings.Replace(createGNTTable, "{{ TABLE_NAME }}", s.tableName, -1)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	var prepared []mssqlWord
-	for _, word := range words {
-		m, _ := json.Marshal(word)
-		prepared = append(prepared, mssqlWord{
-			ID:   word.SequenceID,
-			Data: string(m),
-		})
-	}
-	return PartitionAndPersist(db, tableName, bookName, prepared)
+	return s.partitionAndPersist(ctx, bookName, len(words), func(i int) (mssqlWord, error) {
+		m, err := json.Marshal(words[i])
+		return mssqlWord{ID: words[i].ID, Data: string(m)}, err
+	})
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
-	db, err := createConnection()
+func (s *mssqlSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	sql := strings.Replace(createDocumentTable, "{{ TABLE_NAME }}", s.tableName, -1)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
-	sql := strings.Replace(createGNTTable, "{{ TABLE_NAME }}", tableName, -1)
-	_, err = db.Exec(sql)
+	return s.partitionAndPersist(ctx, bookName, len(documents), func(i int) (mssqlWord, error) {
+		m, err := json.Marshal(documents[i])
+		id, _ := strconv.ParseInt(documents[i].ID, 10, 64)
+		return mssqlWord{ID: id, Data: string(m)}, err
+	})
+}
+
+func (s *mssqlSink) PostPersistDocuments(ctx context.Context) error {
+	sql := strings.Replace(createDocumentIndexes, "{{ TABLE_NAME }}", s.tableName, -1)
+	_, err := s.db.ExecContext(ctx, sql)
 	if err != nil {
 		return err
 	}
-	var prepared []mssqlWord
-	for _, word := range words {
-		m, _ := json.Marshal(word)
-		prepared = append(prepared, mssqlWord{
-			ID:   word.ID,
-			Data: string(m),
-		})
-	}
-	return PartitionAndPersist(db, tableName, bookName, prepared)
-}
-
-func PartitionAndPersist(db *sql.DB, tableName string, bookName string, prepared []mssqlWord) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, len(prepared))
-	for idxRange := range util.Partition(len(prepared), PartitionSize) {
-		// fmt.Printf("Partition: %d %d %d\n", idxRange.Low, idxRange.High, idxRange.High-idxRange.Low)
-		segment := prepared[idxRange.Low:idxRange.High]
-		err := persist(db, tableName, segment)
-		if err != nil {
-			return err
-		}
-		percent := (float64(segmentNumber) * float64((PartitionSize)) / float64(len(prepared))) * 100
-		if percent > 100 {
-			percent = 100
+	return nil
+}
+
+// partitionAndPersist copies size rows, encoding each one once as its
+// partition is copied
+func (s *mssqlSink) partitionAndPersist(ctx context.Context, bookName string, size int, record func(i int) (mssqlWord, error)) error {
+	//+ one writer, since rows aren't keyed: a partition finished out of order
+	//+ would be copied twice on -resume
+	return partitionAndPersist(ctx, s.progress, "mssql", bookName, size, s.getPartitionSize(), 1, func(ctx context.Context, low int, high int) error {
+		return s.persist(ctx, low, high, record)
+	})
+}
+
+// persist copies a partition, trying the copy again while it fails for a
+// transient reason
+func (s *mssqlSink) persist(ctx context.Context, low int, high int, record func(i int) (mssqlWord, error)) error {
+	return s.writer.write(ctx, func() int { return high - low }, mssqlRetryable, func() error {
+		return s.copy(ctx, low, high, record)
+	})
+}
+
+// mssqlRetryable accepts broken connections, deadlocks, timeouts and the
+// errors Azure SQL returns while it's busy or failing over
+func mssqlRetryable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var sqlErr mssql.Error
+	if errors.As(err, &sqlErr) {
+		switch sqlErr.Number {
+		case 1205, 4060, 4221, 10928, 10929, 40197, 40501, 40613, 49918, 49919, 49920:
+			return true
 		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
+		return false
 	}
-	return nil
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
-func persist(db *sql.DB, tableName string, segment []mssqlWord) error {
-	txn, err := db.Begin()
+// copy copies a partition in one transaction, so a cancelled or failed
+// copy is rolled back and can be written again
+func (s *mssqlSink) copy(ctx context.Context, low int, high int, record func(i int) (mssqlWord, error)) error {
+	txn, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	committed := false
+	defer func() {
+		if !committed {
+			txn.Rollback()
+		}
+	}()
 
-	stmt, err := txn.Prepare(mssql.CopyIn(tableName, mssql.BulkOptions{}, "Content"))
+	stmt, err := txn.Prepare(mssql.CopyIn(s.tableName, mssql.BulkOptions{}, "Content"))
 	if err != nil {
 		return err
 	}
 
-	for _, word := range segment {
+	for i := low; i < high; i++ {
+		word, err := record(i)
+		if err != nil {
+			return err
+		}
 		_, err = stmt.Exec(word.Data)
 		if err != nil {
 			return err
@@ -231,5 +313,13 @@ func persist(db *sql.DB, tableName string, segment []mssqlWord) error {
 	if err != nil {
 		return err
 	}
+	committed = true
 	return nil
 }
+
+func (s *mssqlSink) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -1,87 +1,81 @@
-//go:build print
-// +build print
-
 package platform
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/davidbetz/morph/internal/checkpoint"
 	"github.com/davidbetz/morph/internal/models"
-	"github.com/davidbetz/morph/internal/util"
 )
 
-func getPartitionSize() int {
+type printSink struct {
+	progress *checkpoint.Checkpoint
+}
+
+func init() {
+	Register("print", func() Sink { return &printSink{} })
+}
+
+func (s *printSink) getPartitionSize() int {
 	return 100
 }
 
-func ValidateCloudConfig() error {
+func (s *printSink) Open(ctx context.Context, config Config) error {
+	s.progress = config.Checkpoint
 	return nil
 }
 
-func unifiedPersist(tableName string, bookName string, words []interface{}) error {
-	var prepared []string
-	for _, word := range words {
-		output, err := json.MarshalIndent(word, "  ", " ")
-		if err != nil {
-			return err
-		}
-		prepared = append(prepared, string(output))
-	}
-	err := PartitionAndPersist(bookName, prepared)
-	if err != nil {
-		return err
-	}
-	return nil
+// unifiedPersist prints size records, encoding each one once as its
+// partition is printed
+func (s *printSink) unifiedPersist(ctx context.Context, bookName string, size int, record func(i int) interface{}) error {
+	//+ one writer keeps the output in order
+	return partitionAndPersist(ctx, s.progress, "print", bookName, size, s.getPartitionSize(), 1, func(ctx context.Context, low int, high int) error {
+		return s.persist(low, high, record)
+	})
 }
 
-func PrepareAndPersistWlc(tableName string, bookName string, words []models.WlcWord) error {
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *printSink) PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PrepareAndPersistGnt(tableName string, bookName string, words []models.GntWord) error {
-	var taco []interface{}
-	m, _ := json.Marshal(words)
-	json.Unmarshal(m, &taco)
-	return unifiedPersist(tableName, bookName, taco)
+func (s *printSink) PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error {
+	return s.unifiedPersist(ctx, bookName, len(words), func(i int) interface{} {
+		return words[i]
+	})
 }
 
-func PartitionAndPersist(bookName string, prepared []string) error {
-	PartitionSize := getPartitionSize()
-	fmt.Printf("Partition size: %d\n", PartitionSize)
-	segmentNumber := 1
-	fmt.Printf("Saving %s (%d words)...\n", bookName, len(prepared))
-	for idxRange := range util.Partition(len(prepared), PartitionSize) {
-		// fmt.Printf("Partition: %d %d %d\n", idxRange.Low, idxRange.High, idxRange.High-idxRange.Low)
-		segment := prepared[idxRange.Low:idxRange.High]
-		err := persist(segment)
+func (s *printSink) persist(low int, high int, record func(i int) interface{}) error {
+	for i := low; i < high; i++ {
+		output, err := json.MarshalIndent(record(i), "  ", " ")
 		if err != nil {
 			return err
 		}
-		percent := (float64(segmentNumber) * float64((PartitionSize)) / float64(len(prepared))) * 100
-		if percent > 100 {
-			percent = 100
-		}
-		fmt.Printf("%s %0.2f%% complete\n", bookName, percent)
-		segmentNumber++
+		fmt.Printf("Length: %d\n", len(output))
 	}
 	return nil
 }
 
-func persist(words []string) error {
-	for _, obj := range words {
-		fmt.Printf("Length: %d\n", len(obj))
-	}
+func (s *printSink) PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error {
+	return s.unifiedPersist(ctx, bookName, len(documents), func(i int) interface{} {
+		return documents[i]
+	})
+}
+
+func (s *printSink) PostPersistWlc(ctx context.Context) error {
+	return nil
+}
+
+func (s *printSink) PostPersistGnt(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistWLC(tableName string) error {
+func (s *printSink) PostPersistDocuments(ctx context.Context) error {
 	return nil
 }
 
-func PostPersistGNT(tableName string) error {
+func (s *printSink) Close() error {
 	return nil
 }
@@ -0,0 +1,39 @@
+package platform
+
+import (
+	"fmt"
+	"sync"
+)
+
+// progress reports how much of a book a sink has saved, adding up the
+// partitions its workers finish in whatever order they finish
+type progress struct {
+	mu       sync.Mutex
+	sinkName string
+	bookName string
+	size     int
+	saved    int
+}
+
+func newProgress(sinkName string, bookName string, size int) *progress {
+	return &progress{sinkName: sinkName, bookName: bookName, size: size}
+}
+
+// skip counts records an earlier run saved without reporting them
+func (p *progress) skip(records int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saved += records
+}
+
+// add counts a saved partition of records and reports the total
+func (p *progress) add(records int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saved += records
+	percent := 100.0
+	if p.size > 0 {
+		percent = float64(p.saved) / float64(p.size) * 100
+	}
+	fmt.Printf("[%s] %s %0.2f%% complete (%d/%d)\n", p.sinkName, p.bookName, percent, p.saved, p.size)
+}
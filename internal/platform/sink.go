@@ -0,0 +1,177 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/davidbetz/morph/internal/checkpoint"
+	"github.com/davidbetz/morph/internal/models"
+	"github.com/davidbetz/morph/internal/retry"
+	"github.com/davidbetz/morph/internal/util"
+)
+
+// Config is what an import tells each sink it opens
+type Config struct {
+	TableName string
+	// Checkpoint records the partitions and books the sink saves, and
+	// skips those an earlier run of the same import saved. It may be nil.
+	Checkpoint *checkpoint.Checkpoint
+	// Concurrency is how many partitions of a book the sinks that take
+	// parallel writers save at once. Less than one means one.
+	Concurrency int
+	// Retry is how the cloud sinks retry transient failures. The zero
+	// Policy means retry.Default.
+	Retry retry.Policy
+	// WritesPerSecond limits the records each cloud sink writes per
+	// second, shared by its workers. Zero is unlimited.
+	WritesPerSecond float64
+}
+
+// workers is Concurrency, at least one
+func (c Config) workers() int {
+	if c.Concurrency < 1 {
+		return 1
+	}
+	return c.Concurrency
+}
+
+// Sink persists parsed books to a storage platform
+type Sink interface {
+	Open(ctx context.Context, config Config) error
+	PrepareAndPersistWlc(ctx context.Context, bookName string, words []models.WlcWord) error
+	PrepareAndPersistGnt(ctx context.Context, bookName string, words []models.GntWord) error
+	PrepareAndPersistDocuments(ctx context.Context, bookName string, documents []models.Document) error
+	PostPersistWlc(ctx context.Context) error
+	PostPersistGnt(ctx context.Context) error
+	PostPersistDocuments(ctx context.Context) error
+	Close() error
+}
+
+var sinks = make(map[string]func() Sink)
+
+// Register makes a sink available by name
+func Register(name string, create func() Sink) {
+	sinks[name] = create
+}
+
+// Names returns the registered sink names in sorted order
+func Names() []string {
+	var names []string
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create creates the sink registered under name
+func Create(name string) (Sink, error) {
+	create, ok := sinks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q: %s", name, strings.Join(Names(), "|"))
+	}
+	return create(), nil
+}
+
+// partitionAndPersist saves a book a partition at a time, up to workers
+// partitions at once. Partitions are recorded in the checkpoint in order,
+// so -resume writes again any that finished ahead of one still in flight.
+// Once ctx is cancelled or a partition fails no new partition is started;
+// those in flight are left to persist, which finishes or rolls them back.
+func partitionAndPersist(ctx context.Context, checkpoints *checkpoint.Checkpoint, sinkName string, bookName string, size int, partitionSize int, workers int, persist func(ctx context.Context, low int, high int) error) error {
+	if checkpoints.Done(sinkName, bookName) {
+		fmt.Printf("[%s] %s already saved\n", sinkName, bookName)
+		return nil
+	}
+	fmt.Printf("[%s] Partition size: %d\n", sinkName, partitionSize)
+	var ranges [][2]int
+	for idxRange := range util.Partition(size, partitionSize) {
+		ranges = append(ranges, [2]int{idxRange.Low, idxRange.High})
+	}
+	saved := checkpoints.Partitions(sinkName, bookName)
+	if saved > len(ranges) {
+		saved = len(ranges)
+	}
+	report := newProgress(sinkName, bookName, size)
+	if saved > 0 {
+		fmt.Printf("[%s] Resuming %s after %d partitions\n", sinkName, bookName, saved)
+		report.skip(ranges[saved-1][1])
+	}
+	fmt.Printf("[%s] Saving %s (%d words)...\n", sinkName, bookName, size)
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	var failure error
+	finished := make(map[int]bool)
+	next := saved
+	//+ a partition is checkpointed only once every partition before it is
+	complete := func(index int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		finished[index] = true
+		for finished[next] {
+			delete(finished, next)
+			err := checkpoints.SavePartition(sinkName, bookName, next, ranges[next][1]-ranges[next][0])
+			if err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	}
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = err
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return failure != nil
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				low, high := ranges[index][0], ranges[index][1]
+				err := persist(ctx, low, high)
+				if err == nil {
+					err = complete(index)
+				}
+				if err != nil {
+					fail(err)
+					continue
+				}
+				report.add(high - low)
+			}
+		}()
+	}
+	for index := saved; index < len(ranges); index++ {
+		if ctx.Err() != nil || failed() {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		//+ report the cancellation, not however the backend failed on it
+		return ctx.Err()
+	}
+	if failure != nil {
+		return failure
+	}
+	return checkpoints.SaveBook(sinkName, bookName)
+}
@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/davidbetz/morph/internal/retry"
+	"golang.org/x/time/rate"
+)
+
+// writer writes the records of one cloud sink within its rate limit,
+// retrying transient failures. Its limiter is shared by all the sink's
+// workers.
+type writer struct {
+	sinkName string
+	policy   retry.Policy
+	limiter  *rate.Limiter
+}
+
+// newWriter creates the writer of sinkName for a run opened with config
+func newWriter(sinkName string, config Config) *writer {
+	w := &writer{sinkName: sinkName, policy: config.Retry}
+	if w.policy.Attempts == 0 {
+		w.policy = retry.Default
+	}
+	if config.WritesPerSecond > 0 {
+		w.limiter = rate.NewLimiter(rate.Limit(config.WritesPerSecond), int(math.Ceil(config.WritesPerSecond)))
+	}
+	return w
+}
+
+// wait blocks until the sink may write another records records
+func (w *writer) wait(ctx context.Context, records int) error {
+	if w.limiter == nil {
+		return nil
+	}
+	//+ WaitN refuses more than a burst at once
+	for records > 0 {
+		n := records
+		if n > w.limiter.Burst() {
+			n = w.limiter.Burst()
+		}
+		err := w.limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		records -= n
+	}
+	return nil
+}
+
+// write runs op within the rate limit, and retries it under the retry
+// policy while it fails with errors retryable calls transient. Each
+// attempt waits for as many records as records returns at the time, so a
+// retry of part of a batch only pays for that part.
+func (w *writer) write(ctx context.Context, records func() int, retryable retry.Classifier, op func() error) error {
+	notify := func(attempt int, err error, backoff time.Duration) {
+		fmt.Printf("[%s] Attempt %d of %d failed: %s; retrying in %s\n", w.sinkName, attempt, w.policy.Attempts, err.Error(), backoff.Round(time.Millisecond))
+	}
+	return w.policy.Do(ctx, retryable, notify, func() error {
+		err := w.wait(ctx, records())
+		if err != nil {
+			return err
+		}
+		return op()
+	})
+}
@@ -0,0 +1,108 @@
+// Package retry runs an operation again when it fails for a transient
+// reason, backing off exponentially with jitter between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Policy is how many times and how patiently an operation is tried
+type Policy struct {
+	// Attempts is the most times the operation runs, the first included.
+	// One means it isn't retried.
+	Attempts int
+	// Delay is the longest wait before the first retry. It doubles with
+	// each retry after that.
+	Delay time.Duration
+	// MaxDelay caps the wait before any one retry
+	MaxDelay time.Duration
+}
+
+// Default tries an operation five times, waiting up to 1s, 2s, 4s and 8s
+var Default = Policy{Attempts: 5, Delay: time.Second, MaxDelay: 30 * time.Second}
+
+// Classifier reports whether an error is transient, so the operation that
+// returned it is worth trying again
+type Classifier func(err error) bool
+
+// Never is the Classifier of a backend with no transient errors
+func Never(err error) bool {
+	return false
+}
+
+type transient struct {
+	err error
+}
+
+func (t *transient) Error() string {
+	return t.err.Error()
+}
+
+func (t *transient) Unwrap() error {
+	return t.err
+}
+
+// Transient marks err as worth retrying whatever the backend's Classifier
+// says, as when only part of a batch was written
+func Transient(err error) error {
+	return &transient{err}
+}
+
+// IsTransient reports whether err was marked by Transient
+func IsTransient(err error) bool {
+	var t *transient
+	return errors.As(err, &t)
+}
+
+// Backoff is the longest wait before retry n, counting the first retry as 1
+func (p Policy) Backoff(n int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < n; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Do runs op until it succeeds, fails with an error that is neither
+// Transient nor accepted by retryable, or has run p.Attempts times. Before
+// each retry it waits a random time up to Backoff, calling notify first if
+// it isn't nil. Cancelling ctx ends the wait, and Do returns ctx.Err().
+func (p Policy) Do(ctx context.Context, retryable Classifier, notify func(attempt int, err error, wait time.Duration), op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= p.Attempts || !IsTransient(err) && (retryable == nil || !retryable(err)) {
+			return err
+		}
+		//+ full jitter spreads out writers that failed together; a negative
+		//+ delay, or one that overflowed, means no wait
+		var wait time.Duration
+		if backoff := p.Backoff(attempt); backoff > 0 {
+			wait = time.Duration(rand.Int63n(int64(backoff)))
+		}
+		if notify != nil {
+			notify(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		n      int
+		delay  time.Duration
+	}{
+		{"first", Default, 1, time.Second},
+		{"doubles", Default, 2, 2 * time.Second},
+		{"doubles again", Default, 4, 8 * time.Second},
+		{"capped", Default, 6, 30 * time.Second},
+		{"stays capped", Default, 100, 30 * time.Second},
+		{"no cap", Policy{Delay: time.Millisecond}, 11, 1024 * time.Millisecond},
+		{"cap below the delay", Policy{Delay: time.Second, MaxDelay: time.Millisecond}, 1, time.Millisecond},
+		{"zero", Policy{}, 3, 0},
+		{"negative", Policy{Delay: -time.Second}, 2, -2 * time.Second},
+		//+ 1s doubled 99 times wraps around to 0
+		{"overflow", Policy{Delay: time.Second}, 100, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.policy.Backoff(test.n); got != test.delay {
+				t.Errorf("got %s, want %s", got, test.delay)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	failed := errors.New("failed")
+	always := func(err error) bool { return true }
+	quick := Policy{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	tests := []struct {
+		name      string
+		policy    Policy
+		retryable Classifier
+		errs      []error
+		calls     int
+		err       error
+	}{
+		{"succeeds", quick, always, []error{nil}, 1, nil},
+		{"retried", quick, always, []error{failed, failed, nil}, 3, nil},
+		{"out of attempts", quick, always, []error{failed, failed, failed, nil}, 3, failed},
+		{"not retryable", quick, Never, []error{failed, nil}, 1, failed},
+		{"no classifier", quick, nil, []error{failed, nil}, 1, failed},
+		{"transient", quick, Never, []error{Transient(failed), nil}, 2, nil},
+		{"transient out of attempts", quick, nil, []error{Transient(failed), Transient(failed), Transient(failed)}, 3, failed},
+		{"one attempt", Policy{Attempts: 1, Delay: time.Millisecond}, always, []error{failed, nil}, 1, failed},
+		{"no attempts", Policy{}, always, []error{failed, nil}, 1, failed},
+		{"zero delay", Policy{Attempts: 3}, always, []error{failed, failed, nil}, 3, nil},
+		{"negative delay", Policy{Attempts: 3, Delay: -time.Second}, always, []error{failed, failed, nil}, 3, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			err := test.policy.Do(context.Background(), test.retryable, func(attempt int, err error, wait time.Duration) {
+				if attempt != calls {
+					t.Errorf("notified of attempt %d after %d calls", attempt, calls)
+				}
+				if backoff := test.policy.Backoff(attempt); wait < 0 || (backoff > 0 && wait >= backoff) || (backoff <= 0 && wait != 0) {
+					t.Errorf("waited %s before retry %d, with a backoff of %s", wait, attempt, backoff)
+				}
+			}, func() error {
+				calls++
+				return test.errs[calls-1]
+			})
+			if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+				t.Errorf("got %v, want %v", err, test.err)
+			}
+			if calls != test.calls {
+				t.Errorf("got %d calls, want %d", calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestDoJitter(t *testing.T) {
+	policy := Policy{Attempts: 200, Delay: 10 * time.Microsecond, MaxDelay: 40 * time.Microsecond}
+	seen := make(map[time.Duration]bool)
+	err := policy.Do(context.Background(), nil, func(attempt int, err error, wait time.Duration) {
+		if backoff := policy.Backoff(attempt); wait < 0 || wait >= backoff {
+			t.Errorf("waited %s before retry %d, want less than %s", wait, attempt, backoff)
+		}
+		seen[wait] = true
+	}, func() error {
+		return Transient(errors.New("throttled"))
+	})
+	if err == nil {
+		t.Fatal("want the last error")
+	}
+	if len(seen) < 2 {
+		t.Errorf("every retry waited the same %v", seen)
+	}
+}
+
+func TestDoCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	start := time.Now()
+	err := Policy{Attempts: 5, Delay: time.Hour}.Do(ctx, nil, func(attempt int, err error, wait time.Duration) {
+		//+ cancelled while waiting to retry
+		cancel()
+	}, func() error {
+		calls++
+		return Transient(errors.New("throttled"))
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 || time.Since(start) > time.Minute {
+		t.Errorf("got %v after %d calls", err, calls)
+	}
+
+	//+ cancelled while the operation runs
+	ctx, cancel = context.WithCancel(context.Background())
+	calls = 0
+	err = Default.Do(ctx, nil, nil, func() error {
+		calls++
+		cancel()
+		return Transient(errors.New("interrupted"))
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	failed := errors.New("failed")
+	if IsTransient(failed) || IsTransient(nil) {
+		t.Error("a plain error isn't transient")
+	}
+	marked := Transient(failed)
+	if !IsTransient(marked) || !errors.Is(marked, failed) || marked.Error() != "failed" {
+		t.Errorf("got %v", marked)
+	}
+	if Never(failed) {
+		t.Error("Never retries nothing")
+	}
+}
@@ -0,0 +1,226 @@
+package versification
+
+import (
+	"sort"
+
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// window bounds how far ahead Align looks to resynchronize after words
+// that appear in only one of the two trees
+const window = 200
+
+// matchRun is how many consecutive words must agree before a
+// resynchronization is accepted
+const matchRun = 3
+
+// Pair aligns the position of a word in the Hebrew tree with its position
+// in the English tree; -1 marks a word missing from that tree
+type Pair struct {
+	Hebrew  int
+	English int
+}
+
+func key(word models.WlcWord) string {
+	return word.Lemma + "|" + word.ID + "|" + word.Codes
+}
+
+func runMatches(hebrew []models.WlcWord, english []models.WlcWord, i int, j int) bool {
+	for k := 0; k < matchRun; k++ {
+		if i+k >= len(hebrew) || j+k >= len(english) {
+			return i+k >= len(hebrew) && j+k >= len(english)
+		}
+		if key(hebrew[i+k]) != key(english[j+k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Align pairs the words of one book parsed with Hebrew and with English
+// versification. Both trees carry the same text in the same order, so the
+// words are walked in step; where one tree has words the other lacks, the
+// nearest point where both agree again is found within a window.
+func Align(hebrew []models.WlcWord, english []models.WlcWord) []Pair {
+	var pairs []Pair
+	i, j := 0, 0
+	for i < len(hebrew) && j < len(english) {
+		if key(hebrew[i]) == key(english[j]) {
+			pairs = append(pairs, Pair{i, j})
+			i++
+			j++
+			continue
+		}
+		skipHebrew, skipEnglish := -1, -1
+		for k := 1; k < window && skipHebrew < 0 && skipEnglish < 0; k++ {
+			if i+k < len(hebrew) && runMatches(hebrew, english, i+k, j) {
+				skipHebrew = k
+			} else if j+k < len(english) && runMatches(hebrew, english, i, j+k) {
+				skipEnglish = k
+			}
+		}
+		switch {
+		case skipHebrew > 0:
+			for ; skipHebrew > 0; skipHebrew-- {
+				pairs = append(pairs, Pair{i, -1})
+				i++
+			}
+		case skipEnglish > 0:
+			for ; skipEnglish > 0; skipEnglish-- {
+				pairs = append(pairs, Pair{-1, j})
+				j++
+			}
+		default:
+			pairs = append(pairs, Pair{i, -1}, Pair{-1, j})
+			i++
+			j++
+		}
+	}
+	for ; i < len(hebrew); i++ {
+		pairs = append(pairs, Pair{i, -1})
+	}
+	for ; j < len(english); j++ {
+		pairs = append(pairs, Pair{-1, j})
+	}
+	return pairs
+}
+
+// Apply sets EnglishReference on every Hebrew word that has a counterpart
+// in the English tree
+func Apply(hebrew []models.WlcWord, english []models.WlcWord, pairs []Pair) {
+	for _, pair := range pairs {
+		if pair.Hebrew < 0 || pair.English < 0 {
+			continue
+		}
+		reference := english[pair.English].Reference
+		hebrew[pair.Hebrew].EnglishReference = &reference
+		hebrew[pair.Hebrew].EnglishVerse = reference.VerseID()
+	}
+}
+
+const (
+	Split       = "split"
+	Merged      = "merged"
+	Renumbered  = "renumbered"
+	HebrewOnly  = "hebrew-only"
+	EnglishOnly = "english-only"
+)
+
+// Difference is a verse whose division or numbering differs between the
+// Hebrew and English schemes
+type Difference struct {
+	Kind    string             `json:"kind"`
+	Hebrew  []models.Reference `json:"hebrew"`
+	English []models.Reference `json:"english"`
+}
+
+type verseSet struct {
+	order []string
+	refs  map[string]models.Reference
+}
+
+func (s *verseSet) add(reference models.Reference) {
+	if s.refs == nil {
+		s.refs = make(map[string]models.Reference)
+	}
+	reference.Word = 0
+	id := reference.VerseID()
+	if _, ok := s.refs[id]; ok {
+		return
+	}
+	s.refs[id] = reference
+	s.order = append(s.order, id)
+}
+
+func (s *verseSet) list() []models.Reference {
+	var refs []models.Reference
+	for _, id := range s.order {
+		refs = append(refs, s.refs[id])
+	}
+	return refs
+}
+
+// Compare lists every verse that is split, merged, renumbered or present
+// in only one scheme
+func Compare(hebrew []models.WlcWord, english []models.WlcWord, pairs []Pair) []Difference {
+	toEnglish := make(map[string]*verseSet)
+	toHebrew := make(map[string]*verseSet)
+	var hebrewOrder []string
+	var englishOrder []string
+	for _, pair := range pairs {
+		if pair.Hebrew >= 0 {
+			id := hebrew[pair.Hebrew].Verse
+			if _, ok := toEnglish[id]; !ok {
+				toEnglish[id] = &verseSet{}
+				hebrewOrder = append(hebrewOrder, id)
+			}
+			if pair.English >= 0 {
+				toEnglish[id].add(english[pair.English].Reference)
+			}
+		}
+		if pair.English >= 0 {
+			id := english[pair.English].Verse
+			if _, ok := toHebrew[id]; !ok {
+				toHebrew[id] = &verseSet{}
+				englishOrder = append(englishOrder, id)
+			}
+			if pair.Hebrew >= 0 {
+				toHebrew[id].add(hebrew[pair.Hebrew].Reference)
+			}
+		}
+	}
+	firstWord := make(map[string]models.Reference)
+	for _, word := range hebrew {
+		if _, ok := firstWord[word.Verse]; !ok {
+			reference := word.Reference
+			reference.Word = 0
+			firstWord[word.Verse] = reference
+		}
+	}
+	var differences []Difference
+	reported := make(map[string]bool)
+	for _, id := range hebrewOrder {
+		targets := toEnglish[id].list()
+		switch {
+		case len(targets) == 0:
+			differences = append(differences, Difference{Kind: HebrewOnly, Hebrew: []models.Reference{firstWord[id]}})
+		case len(targets) > 1:
+			differences = append(differences, Difference{Kind: Split, Hebrew: []models.Reference{firstWord[id]}, English: targets})
+		default:
+			target := targets[0].VerseID()
+			sources := toHebrew[target].list()
+			if len(sources) > 1 {
+				if !reported[target] {
+					reported[target] = true
+					differences = append(differences, Difference{Kind: Merged, Hebrew: sources, English: targets})
+				}
+			} else if target != id {
+				differences = append(differences, Difference{Kind: Renumbered, Hebrew: []models.Reference{firstWord[id]}, English: targets})
+			}
+		}
+	}
+	englishFirst := make(map[string]models.Reference)
+	for _, word := range english {
+		if _, ok := englishFirst[word.Verse]; !ok {
+			reference := word.Reference
+			reference.Word = 0
+			englishFirst[word.Verse] = reference
+		}
+	}
+	for _, id := range englishOrder {
+		if len(toHebrew[id].order) == 0 {
+			differences = append(differences, Difference{Kind: EnglishOnly, English: []models.Reference{englishFirst[id]}})
+		}
+	}
+	sort.SliceStable(differences, func(a int, b int) bool {
+		return sortKey(differences[a]) < sortKey(differences[b])
+	})
+	return differences
+}
+
+func sortKey(d Difference) int64 {
+	if len(d.Hebrew) > 0 {
+		return d.Hebrew[0].WordID()
+	}
+	return d.English[0].WordID()
+}
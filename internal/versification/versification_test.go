@@ -0,0 +1,141 @@
+package versification
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/davidbetz/morph/internal/canon"
+	"github.com/davidbetz/morph/internal/models"
+)
+
+// tree builds the words of Genesis from verses written as "chapter:verse
+// lemma lemma ..." and separated by semicolons
+func tree(t *testing.T, spec string) []models.WlcWord {
+	t.Helper()
+	var words []models.WlcWord
+	for _, verse := range strings.Split(spec, ";") {
+		fields := strings.Fields(verse)
+		if len(fields) == 0 {
+			continue
+		}
+		chapter, number, ok := strings.Cut(fields[0], ":")
+		c, err := strconv.Atoi(chapter)
+		if err != nil || !ok {
+			t.Fatalf("bad verse %q", fields[0])
+		}
+		v, err := strconv.Atoi(number)
+		if err != nil {
+			t.Fatalf("bad verse %q", fields[0])
+		}
+		for n, lemma := range fields[1:] {
+			reference := canon.NewReference(1, c, v, n+1)
+			words = append(words, models.WlcWord{Lemma: lemma, ID: lemma, Codes: "HNcmsa", Verse: reference.VerseID(), Reference: reference})
+		}
+	}
+	return words
+}
+
+func formatPairs(pairs []Pair) string {
+	var parts []string
+	for _, pair := range pairs {
+		part := ""
+		if pair.Hebrew >= 0 {
+			part += strconv.Itoa(pair.Hebrew)
+		}
+		part += "-"
+		if pair.English >= 0 {
+			part += strconv.Itoa(pair.English)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatReferences(references []models.Reference) string {
+	var parts []string
+	for _, reference := range references {
+		parts = append(parts, fmt.Sprintf("%d:%d", reference.Chapter, reference.Verse))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		name    string
+		hebrew  string
+		english string
+		pairs   string
+	}{
+		{"identical", "1:1 a b c; 1:2 d e", "1:1 a b c; 1:2 d e", "0-0 1-1 2-2 3-3 4-4"},
+		{"verse numbers don't matter", "1:1 a b; 1:2 c", "1:1 a; 1:2 b c", "0-0 1-1 2-2"},
+		{"extra Hebrew word", "1:1 a x b c d", "1:1 a b c d", "0-0 1- 2-1 3-2 4-3"},
+		{"extra English word", "1:1 a b c d", "1:1 a y b c d", "0-0 -1 1-2 2-3 3-4"},
+		{"extra Hebrew run", "1:1 a x y z b c d", "1:1 a b c d", "0-0 1- 2- 3- 4-1 5-2 6-3"},
+		{"no resynchronization", "1:1 a x b", "1:1 a y b", "0-0 1- -1 2-2"},
+		{"short run doesn't resynchronize", "1:1 a x b c", "1:1 a b d c", "0-0 1- -1 2- -2 3-3"},
+		{"trailing Hebrew", "1:1 a b c d", "1:1 a b", "0-0 1-1 2- 3-"},
+		{"trailing English", "1:1 a", "1:1 a b", "0-0 -1"},
+		{"empty", "", "1:1 a", "-0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pairs := Align(tree(t, test.hebrew), tree(t, test.english))
+			if got := formatPairs(pairs); got != test.pairs {
+				t.Errorf("got %s, want %s", got, test.pairs)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	hebrew := tree(t, "1:1 a x b; 1:2 c")
+	english := tree(t, "1:1 a; 1:2 b c")
+	Apply(hebrew, english, Align(hebrew, english))
+	want := []string{"1:1.1", "", "1:2.1", "1:2.2"}
+	for i, word := range hebrew {
+		got := ""
+		if word.EnglishReference != nil {
+			got = fmt.Sprintf("%d:%d.%d", word.EnglishReference.Chapter, word.EnglishReference.Verse, word.EnglishReference.Word)
+			if word.EnglishVerse != word.EnglishReference.VerseID() {
+				t.Errorf("%s: EnglishVerse %s doesn't match %s", word.Lemma, word.EnglishVerse, got)
+			}
+		} else if len(word.EnglishVerse) > 0 {
+			t.Errorf("%s: EnglishVerse %s without an EnglishReference", word.Lemma, word.EnglishVerse)
+		}
+		if got != want[i] {
+			t.Errorf("%s: got %q, want %q", word.Lemma, got, want[i])
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		hebrew      string
+		english     string
+		differences []string
+	}{
+		{"identical", "1:1 a b; 1:2 c d", "1:1 a b; 1:2 c d", nil},
+		{"renumbered", "1:1 a b; 1:2 c d", "1:1 a b; 1:3 c d", []string{"renumbered 1:2>1:3"}},
+		{"split", "1:1 a b c d", "1:1 a b; 1:2 c d", []string{"split 1:1>1:1,1:2"}},
+		{"merged", "1:1 a b; 1:2 c d", "1:1 a b c d", []string{"merged 1:1,1:2>1:1"}},
+		{"Hebrew only", "1:1 a b c; 1:2 x; 1:3 d e f", "1:1 a b c; 1:3 d e f", []string{"hebrew-only 1:2>"}},
+		{"English only", "1:1 a b c; 1:3 d e f", "1:1 a b c; 1:2 y; 1:3 d e f", []string{"english-only >1:2"}},
+		{"sorted by verse", "1:1 a b c; 2:1 d e; 2:2 f g; 3:1 h i", "1:1 a b c; 2:1 d e f g; 3:2 h i", []string{"merged 2:1,2:2>2:1", "renumbered 3:1>3:2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hebrew := tree(t, test.hebrew)
+			english := tree(t, test.english)
+			var got []string
+			for _, difference := range Compare(hebrew, english, Align(hebrew, english)) {
+				got = append(got, difference.Kind+" "+formatReferences(difference.Hebrew)+">"+formatReferences(difference.English))
+			}
+			if strings.Join(got, "; ") != strings.Join(test.differences, "; ") {
+				t.Errorf("got %q, want %q", got, test.differences)
+			}
+		})
+	}
+}